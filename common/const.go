@@ -2,4 +2,7 @@ package common
 
 const (
 	TX_CONFIRM_TIMEOUT = 21
+	// FILE_BLOCK_SIZE_KB is the size of a file block. Node and space volumes
+	// are counted in KB.
+	FILE_BLOCK_SIZE_KB = 256
 )
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	ont "github.com/ontio/ontology-go-sdk"
+)
+
+// accountCache keeps the wallet accounts already decrypted, so that every
+// account is unlocked once for a Core and all the views derived from it.
+type accountCache struct {
+	lock      sync.Mutex
+	byAddress map[string]*ont.Account
+	byLabel   map[string]*ont.Account
+}
+
+func newAccountCache() *accountCache {
+	return &accountCache{
+		byAddress: make(map[string]*ont.Account),
+		byLabel:   make(map[string]*ont.Account),
+	}
+}
+
+func (a *accountCache) add(acc *ont.Account, label string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.byAddress[acc.Address.ToBase58()] = acc
+	if len(label) != 0 {
+		a.byLabel[label] = acc
+	}
+}
+
+// WithAccount returns a view of c that signs with acc and uses its address as
+// the file owner, node address and space owner. The view shares everything
+// else with c, including its confirmation tracker.
+func (c *Core) WithAccount(acc *ont.Account) *Core {
+	view := *c
+	view.Signer = nil
+	view.DefAcc = acc
+	view.WalletAddr = acc.Address
+	return &view
+}
+
+// WithAccountAddress returns a view of c bound to the wallet account with the
+// base58 address, unlocked with the wallet password.
+func (c *Core) WithAccountAddress(address string) (*Core, error) {
+	acc, err := c.walletAccount(address, "", func(wallet *ont.Wallet) (*ont.Account, error) {
+		return wallet.GetAccountByAddress(address, c.Password)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.WithAccount(acc), nil
+}
+
+// WithAccountLabel returns a view of c bound to the wallet account with label,
+// unlocked with the wallet password.
+func (c *Core) WithAccountLabel(label string) (*Core, error) {
+	acc, err := c.walletAccount("", label, func(wallet *ont.Wallet) (*ont.Account, error) {
+		return wallet.GetAccountByLabel(label, c.Password)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.WithAccount(acc), nil
+}
+
+func (c *Core) walletAccount(address string, label string,
+	open func(wallet *ont.Wallet) (*ont.Account, error)) (*ont.Account, error) {
+	if c.Wallet == nil || c.accounts == nil {
+		return nil, errors.New("no wallet is opened")
+	}
+	cache := c.accounts
+	cache.lock.Lock()
+	acc, ok := cache.byAddress[address]
+	if len(label) != 0 {
+		acc, ok = cache.byLabel[label]
+	}
+	cache.lock.Unlock()
+	if ok {
+		return acc, nil
+	}
+
+	acc, err := open(c.Wallet)
+	if err != nil {
+		return nil, fmt.Errorf("wallet account %s%s error: %s", address, label, err.Error())
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("wallet account %s%s not found", address, label)
+	}
+	cache.add(acc, label)
+	return acc, nil
+}
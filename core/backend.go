@@ -0,0 +1,131 @@
+package core
+
+import (
+	"fmt"
+
+	ont "github.com/ontio/ontology-go-sdk"
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+// ChainBackend is the set of chain calls Core relies on to invoke the ontfs
+// native contract and to track the resulting transactions.
+type ChainBackend interface {
+	InvokeNativeContract(gasPrice, gasLimit uint64, signer Signer, version byte,
+		contractAddress ccom.Address, method string, params []interface{}) (ccom.Uint256, error)
+	PreExecInvokeNativeContract(contractAddress ccom.Address, version byte, method string,
+		params []interface{}) (*sdkcom.PreExecResult, error)
+	GetBlockHeightByTxHash(txHash string) (uint32, error)
+	GetSmartContractEvent(txHash string) (*sdkcom.SmartContactEvent, error)
+}
+
+// BlockBackend is optionally implemented by a ChainBackend that can report the
+// current block. GetFileList needs it to build a passport.
+type BlockBackend interface {
+	GetCurrentBlockHeight() (uint32, error)
+	GetBlockHash(height uint32) (ccom.Uint256, error)
+}
+
+// BlockTimeBackend is optionally implemented by a ChainBackend that can report
+// the timestamp of a block. With BlockBackend, it gives Core the chain time
+// the contract checks expiry against, the local clock being used otherwise.
+type BlockTimeBackend interface {
+	GetBlockTimestamp(height uint32) (uint32, error)
+}
+
+// EstimateBackend is optionally implemented by a ChainBackend that can
+// pre-execute an invocation signed by signer, so that the contract sees the
+// witness of the signer. Core uses it to estimate the gas of a transaction.
+type EstimateBackend interface {
+	PreExecSignedNativeContract(gasPrice, gasLimit uint64, signer Signer, version byte,
+		contractAddress ccom.Address, method string, params []interface{}) (*sdkcom.PreExecResult, error)
+}
+
+// TxBackend is optionally implemented by a ChainBackend that can build the
+// transaction of an invocation and send or pre-execute it once signed. Core
+// needs it for transactions co-signed by a Payer.
+type TxBackend interface {
+	NewNativeInvokeTransaction(gasPrice, gasLimit uint64, version byte, contractAddress ccom.Address,
+		method string, params []interface{}) (*types.MutableTransaction, error)
+	SendTransaction(tx *types.MutableTransaction) (ccom.Uint256, error)
+	PreExecTransaction(tx *types.MutableTransaction) (*sdkcom.PreExecResult, error)
+}
+
+// SdkBackend is the default ChainBackend, backed by an ontology-go-sdk client.
+type SdkBackend struct {
+	OntSdk *ont.OntologySdk
+}
+
+func NewSdkBackend(ontSdk *ont.OntologySdk) *SdkBackend {
+	return &SdkBackend{OntSdk: ontSdk}
+}
+
+func (b *SdkBackend) InvokeNativeContract(gasPrice, gasLimit uint64, signer Signer, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (ccom.Uint256, error) {
+	tx, err := b.OntSdk.Native.NewNativeInvokeTransaction(gasPrice, gasLimit, version, contractAddress, method, params)
+	if err != nil {
+		return ccom.UINT256_EMPTY, err
+	}
+	if err = signer.SignTransaction(tx); err != nil {
+		return ccom.UINT256_EMPTY, err
+	}
+	return b.OntSdk.SendTransaction(tx)
+}
+
+func (b *SdkBackend) PreExecInvokeNativeContract(contractAddress ccom.Address, version byte, method string,
+	params []interface{}) (*sdkcom.PreExecResult, error) {
+	return b.OntSdk.Native.PreExecInvokeNativeContract(contractAddress, version, method, params)
+}
+
+func (b *SdkBackend) PreExecSignedNativeContract(gasPrice, gasLimit uint64, signer Signer, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (*sdkcom.PreExecResult, error) {
+	tx, err := b.OntSdk.Native.NewNativeInvokeTransaction(gasPrice, gasLimit, version, contractAddress, method, params)
+	if err != nil {
+		return nil, err
+	}
+	if err = signer.SignTransaction(tx); err != nil {
+		return nil, err
+	}
+	return b.OntSdk.PreExecTransaction(tx)
+}
+
+func (b *SdkBackend) NewNativeInvokeTransaction(gasPrice, gasLimit uint64, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (*types.MutableTransaction, error) {
+	return b.OntSdk.Native.NewNativeInvokeTransaction(gasPrice, gasLimit, version, contractAddress, method, params)
+}
+
+func (b *SdkBackend) SendTransaction(tx *types.MutableTransaction) (ccom.Uint256, error) {
+	return b.OntSdk.SendTransaction(tx)
+}
+
+func (b *SdkBackend) PreExecTransaction(tx *types.MutableTransaction) (*sdkcom.PreExecResult, error) {
+	return b.OntSdk.PreExecTransaction(tx)
+}
+
+func (b *SdkBackend) GetBlockHeightByTxHash(txHash string) (uint32, error) {
+	return b.OntSdk.GetBlockHeightByTxHash(txHash)
+}
+
+func (b *SdkBackend) GetSmartContractEvent(txHash string) (*sdkcom.SmartContactEvent, error) {
+	return b.OntSdk.GetSmartContractEvent(txHash)
+}
+
+func (b *SdkBackend) GetCurrentBlockHeight() (uint32, error) {
+	return b.OntSdk.GetCurrentBlockHeight()
+}
+
+func (b *SdkBackend) GetBlockHash(height uint32) (ccom.Uint256, error) {
+	return b.OntSdk.GetBlockHash(height)
+}
+
+func (b *SdkBackend) GetBlockTimestamp(height uint32) (uint32, error) {
+	block, err := b.OntSdk.GetBlockByHeight(height)
+	if err != nil {
+		return 0, err
+	}
+	if block == nil || block.Header == nil {
+		return 0, fmt.Errorf("block %d not found", height)
+	}
+	return block.Header.Timestamp, nil
+}
@@ -0,0 +1,435 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// BatchErrorCode categorizes the message reported by the contract for one
+// file of a batch.
+type BatchErrorCode int
+
+const (
+	BatchErrNone BatchErrorCode = iota
+	BatchErrUnknown
+	BatchErrUnauthorized
+	BatchErrNotFound
+	BatchErrExists
+	BatchErrInsufficient
+	BatchErrInvalidParam
+	BatchErrInSpace
+	// BatchErrTxFailed marks the items of a transaction that could not be
+	// submitted or confirmed.
+	BatchErrTxFailed
+)
+
+func (code BatchErrorCode) String() string {
+	switch code {
+	case BatchErrNone:
+		return "none"
+	case BatchErrUnauthorized:
+		return "unauthorized"
+	case BatchErrNotFound:
+		return "not found"
+	case BatchErrExists:
+		return "exists"
+	case BatchErrInsufficient:
+		return "insufficient"
+	case BatchErrInvalidParam:
+		return "invalid param"
+	case BatchErrInSpace:
+		return "stored in space"
+	case BatchErrTxFailed:
+		return "tx failed"
+	default:
+		return "unknown"
+	}
+}
+
+var batchErrorPatterns = []struct {
+	pattern string
+	code    BatchErrorCode
+}{
+	{"checkwitness", BatchErrUnauthorized},
+	{"owner is wrong", BatchErrUnauthorized},
+	{"not found", BatchErrNotFound},
+	{"not exist", BatchErrNotFound},
+	{"has stored", BatchErrExists},
+	{"has existed", BatchErrExists},
+	{"no enough", BatchErrInsufficient},
+	{"not enough", BatchErrInsufficient},
+	{"insufficient", BatchErrInsufficient},
+	{"balance", BatchErrInsufficient},
+	{"stored in space", BatchErrInSpace},
+	{"too early", BatchErrInvalidParam},
+	{"too small", BatchErrInvalidParam},
+	{"is zero", BatchErrInvalidParam},
+	{"not later", BatchErrInvalidParam},
+	{"expired", BatchErrInvalidParam},
+}
+
+// ClassifyBatchError returns the category of a per-file contract message.
+func ClassifyBatchError(message string) BatchErrorCode {
+	if len(message) == 0 {
+		return BatchErrNone
+	}
+	message = strings.ToLower(message)
+	for _, p := range batchErrorPatterns {
+		if strings.Contains(message, p.pattern) {
+			return p.code
+		}
+	}
+	return BatchErrUnknown
+}
+
+// BatchItem is the outcome of one input of a batch file method. Input holds
+// the common.FileStore, common.FileRenew, common.FileTransfer or, for
+// DeleteFiles, the file hash string passed by the caller.
+type BatchItem struct {
+	FileHash  string
+	TxHash    []byte
+	Input     interface{}
+	Succeeded bool
+	Message   string
+	Code      BatchErrorCode
+}
+
+// BatchChunk is one of the transactions a batch was split into. It holds
+// Items[Start:End] of its BatchResult.
+type BatchChunk struct {
+	TxHash []byte
+	Start  int
+	End    int
+	Err    error
+}
+
+// BatchResult lists the outcome of every input of a batch file method, in
+// input order. TxHash is the hash of the first transaction.
+type BatchResult struct {
+	Method string
+	TxHash []byte
+	Items  []BatchItem
+	Chunks []BatchChunk
+}
+
+// Failed returns the items rejected by the contract.
+func (r *BatchResult) Failed() []BatchItem {
+	return r.filter(false)
+}
+
+// Succeeded returns the items accepted by the contract.
+func (r *BatchResult) Succeeded() []BatchItem {
+	return r.filter(true)
+}
+
+// AllSucceeded reports whether no item was rejected.
+func (r *BatchResult) AllSucceeded() bool {
+	return len(r.Failed()) == 0
+}
+
+// FailedChunks returns the transactions that could not be submitted or
+// confirmed.
+func (r *BatchResult) FailedChunks() []BatchChunk {
+	var chunks []BatchChunk
+	for _, chunk := range r.Chunks {
+		if chunk.Err != nil {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+func (r *BatchResult) filter(succeeded bool) []BatchItem {
+	var items []BatchItem
+	for _, item := range r.Items {
+		if item.Succeeded == succeeded {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// withErrors returns a copy of r, the inputs of transaction txHash, with the
+// items named in objErrors marked as failed.
+func (r *BatchResult) withErrors(txHash []byte, objErrors *fs.Errors) *BatchResult {
+	result := *r
+	result.TxHash = txHash
+	result.Items = append([]BatchItem(nil), r.Items...)
+	result.apply(objErrors)
+	return &result
+}
+
+// apply marks the items named in objErrors as failed.
+func (r *BatchResult) apply(objErrors *fs.Errors) {
+	for i := range r.Items {
+		item := &r.Items[i]
+		message, ok := objErrors.ObjectErrors[item.FileHash]
+		item.Succeeded = !ok
+		item.Message = message
+		item.Code = ClassifyBatchError(message)
+		if ok && item.Code == BatchErrNone {
+			item.Code = BatchErrUnknown
+		}
+	}
+}
+
+func newBatchResult(method string, fileHashes []string, inputs []interface{}) *BatchResult {
+	result := &BatchResult{
+		Method: method,
+		Items:  make([]BatchItem, len(fileHashes)),
+	}
+	for i, fileHash := range fileHashes {
+		result.Items[i] = BatchItem{FileHash: fileHash, Input: inputs[i]}
+	}
+	return result
+}
+
+func storeBatch(filesInfo []common.FileStore) *BatchResult {
+	fileHashes := make([]string, len(filesInfo))
+	inputs := make([]interface{}, len(filesInfo))
+	for i, fileInfo := range filesInfo {
+		fileHashes[i] = fileInfo.FileHash
+		inputs[i] = fileInfo
+	}
+	return newBatchResult(fs.FS_STORE_FILES, fileHashes, inputs)
+}
+
+func renewBatch(filesRenew []common.FileRenew) *BatchResult {
+	fileHashes := make([]string, len(filesRenew))
+	inputs := make([]interface{}, len(filesRenew))
+	for i, fileRenew := range filesRenew {
+		fileHashes[i] = fileRenew.FileHash
+		inputs[i] = fileRenew
+	}
+	return newBatchResult(fs.FS_RENEW_FILES, fileHashes, inputs)
+}
+
+func transferBatch(fileTransfers []common.FileTransfer) *BatchResult {
+	fileHashes := make([]string, len(fileTransfers))
+	inputs := make([]interface{}, len(fileTransfers))
+	for i, fileTransfer := range fileTransfers {
+		fileHashes[i] = fileTransfer.FileHash
+		inputs[i] = fileTransfer
+	}
+	return newBatchResult(fs.FS_TRANSFER_FILES, fileHashes, inputs)
+}
+
+func deleteBatch(fileHashes []string) *BatchResult {
+	inputs := make([]interface{}, len(fileHashes))
+	for i, fileHash := range fileHashes {
+		inputs[i] = fileHash
+	}
+	return newBatchResult(fs.FS_DELETE_FILES, fileHashes, inputs)
+}
+
+// isBatchMethod reports whether method is one of the batch file methods.
+func isBatchMethod(method string) bool {
+	switch method {
+	case fs.FS_STORE_FILES, fs.FS_RENEW_FILES, fs.FS_DELETE_FILES, fs.FS_TRANSFER_FILES:
+		return true
+	}
+	return false
+}
+
+// runBatch splits the items of batch into chunks sized by the batch limits of
+// c, submits one transaction per chunk and merges their outcomes into batch.
+// param serializes the inputs of items [start, end).
+func (c *Core) runBatch(ctx context.Context, batch *BatchResult,
+	param func(start, end int) ([]byte, error)) (*BatchResult, error) {
+	params, err := c.batchParams(ctx, batch, param)
+	if err != nil {
+		return nil, err
+	}
+
+	parallelism := c.BatchParallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := range batch.Chunks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			batch.failChunk(&batch.Chunks[i], ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func(chunk *BatchChunk, param []byte) {
+			defer wg.Done()
+			c.runChunk(ctx, batch, chunk, param)
+			<-sem
+		}(&batch.Chunks[i], params[i])
+	}
+	wg.Wait()
+
+	if len(batch.Chunks) > 0 {
+		batch.TxHash = batch.Chunks[0].TxHash
+	}
+	if failed := batch.FailedChunks(); len(failed) > 0 {
+		return batch, &BatchError{
+			Method: batch.Method,
+			Failed: len(failed),
+			Chunks: len(batch.Chunks),
+			Err:    failed[0].Err,
+		}
+	}
+	return batch, nil
+}
+
+// batchParams sets the chunks of batch and returns their serialized params.
+// Under GasEstimation, the chunks of splitBatch are pre-executed, and those
+// whose gas exceeds GasLimit are split in two until they fit or hold a single
+// item. A chunk that cannot be estimated is left to fail on submission.
+func (c *Core) batchParams(ctx context.Context, batch *BatchResult,
+	param func(start, end int) ([]byte, error)) ([][]byte, error) {
+	params, err := c.splitBatch(batch, param)
+	if err != nil {
+		return nil, err
+	}
+	signer := c.signer()
+	if !c.GasEstimation || !c.canPreExec(signer) {
+		return params, nil
+	}
+
+	chunks := batch.Chunks
+	batch.Chunks = nil
+	var fitted [][]byte
+	var fit func(chunk BatchChunk, data []byte) error
+	fit = func(chunk BatchChunk, data []byte) error {
+		if chunk.End-chunk.Start > 1 {
+			estimate, err := c.estimateGas(ctx, signer, batch.Method, []interface{}{data})
+			if err == nil && estimate.Gas > c.GasLimit {
+				mid := (chunk.Start + chunk.End) / 2
+				for _, half := range []BatchChunk{{Start: chunk.Start, End: mid}, {Start: mid, End: chunk.End}} {
+					data, err := param(half.Start, half.End)
+					if err != nil {
+						return err
+					}
+					if err = fit(half, data); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+		batch.Chunks = append(batch.Chunks, chunk)
+		fitted = append(fitted, data)
+		return nil
+	}
+	for i, chunk := range chunks {
+		if err = fit(chunk, params[i]); err != nil {
+			return nil, err
+		}
+	}
+	return fitted, nil
+}
+
+// splitBatch sets the chunks of batch, sized by the batch limits of c, and
+// returns their serialized params.
+func (c *Core) splitBatch(batch *BatchResult, param func(start, end int) ([]byte, error)) ([][]byte, error) {
+	count := len(batch.Items)
+	if count == 0 {
+		return nil, errors.New(batch.Method + " batch is empty")
+	}
+	gasPerFile := c.BatchGasPerFile
+	if gasPerFile == 0 {
+		gasPerFile = defaultBatchGasPerFile
+	}
+	maxFiles := int(c.GasLimit / gasPerFile)
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+	maxBytes := c.BatchMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultBatchMaxBytes
+	}
+
+	empty, err := param(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make([]int, count)
+	for i := range sizes {
+		data, err := param(i, i+1)
+		if err != nil {
+			return nil, err
+		}
+		sizes[i] = len(data) - len(empty)
+	}
+
+	// the list length prefix grows with the number of items
+	const lengthSlack = 8
+	var params [][]byte
+	for start := 0; start < count; {
+		end := start + 1
+		size := len(empty) + lengthSlack + sizes[start]
+		for end < count && end-start < maxFiles && size+sizes[end] <= maxBytes {
+			size += sizes[end]
+			end++
+		}
+		data, err := param(start, end)
+		if err != nil {
+			return nil, err
+		}
+		batch.Chunks = append(batch.Chunks, BatchChunk{Start: start, End: end})
+		params = append(params, data)
+		start = end
+	}
+	return params, nil
+}
+
+func (c *Core) runChunk(ctx context.Context, batch *BatchResult, chunk *BatchChunk, param []byte) {
+	items := batch.Items[chunk.Start:chunk.End]
+	pending, err := c.invokeAsync(ctx, batch.Method, []interface{}{param},
+		&BatchResult{Method: batch.Method, Items: items})
+	if err != nil {
+		batch.failChunk(chunk, err)
+		return
+	}
+	chunk.TxHash = pending.Hash()
+	receipt, err := pending.Wait(ctx)
+	if err != nil {
+		batch.failChunk(chunk, err)
+		return
+	}
+	copy(items, receipt.Batch.Items)
+	for i := range items {
+		items[i].TxHash = chunk.TxHash
+	}
+}
+
+func (r *BatchResult) failChunk(chunk *BatchChunk, err error) {
+	chunk.Err = err
+	for i := chunk.Start; i < chunk.End; i++ {
+		item := &r.Items[i]
+		item.TxHash = chunk.TxHash
+		item.Succeeded = false
+		item.Message = err.Error()
+		item.Code = BatchErrTxFailed
+	}
+}
+
+// legacyBatchResult converts a BatchResult to the results of the original
+// batch file methods. The errors are nil when the contract rejected no file.
+func legacyBatchResult(result *BatchResult, err error) ([]byte, error, *fs.Errors) {
+	if result == nil {
+		return nil, err, nil
+	}
+	var objErrors *fs.Errors
+	for _, item := range result.Failed() {
+		if item.Code == BatchErrTxFailed {
+			continue
+		}
+		if objErrors == nil {
+			objErrors = &fs.Errors{ObjectErrors: make(map[string]string)}
+		}
+		objErrors.AddObjectError(item.FileHash, item.Message)
+	}
+	return result.TxHash, err, objErrors
+}
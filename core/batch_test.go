@@ -0,0 +1,39 @@
+package core
+
+import (
+	"testing"
+
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+func TestBatchErrors_MergesNotifies(t *testing.T) {
+	first := &fs.Errors{}
+	first.AddObjectError("fileA", "FsStoreFiles file has stored")
+	second := &fs.Errors{}
+	second.AddObjectError("fileB", "FsStoreFiles space has no enough volume")
+	event := &sdkcom.SmartContactEvent{
+		Notify: []*sdkcom.NotifyEventInfo{
+			{ContractAddress: utils.OntFSContractAddress.ToHexString(), States: first.ToString()},
+			{ContractAddress: "other", States: []interface{}{"transfer"}},
+			{ContractAddress: utils.OntFSContractAddress.ToHexString(), States: second.ToString()},
+		},
+	}
+
+	objErrors, err := batchErrors(utils.OntFSContractAddress, event.Notify)
+	if err != nil {
+		t.Fatalf("batchErrors error: %s", err.Error())
+	}
+	result := deleteBatch([]string{"fileA", "fileB", "fileC"})
+	result.apply(objErrors)
+
+	failed := result.Failed()
+	if len(failed) != 2 || failed[0].Code != BatchErrExists || failed[1].Code != BatchErrInsufficient {
+		t.Fatalf("unexpected failed items: %+v", failed)
+	}
+	succeeded := result.Succeeded()
+	if len(succeeded) != 1 || succeeded[0].Input.(string) != "fileC" {
+		t.Fatalf("unexpected succeeded items: %+v", succeeded)
+	}
+}
@@ -0,0 +1,241 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	ccom "github.com/ontio/ontology/common"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// DefaultQueryCacheTTLs caches the global parameters for 5 minutes, and the
+// node and file queries for 30 seconds.
+func DefaultQueryCacheTTLs() map[string]time.Duration {
+	return map[string]time.Duration{
+		fs.FS_GET_GLOBAL_PARAM: 5 * time.Minute,
+		fs.FS_GET_NODE_LIST:    30 * time.Second,
+		fs.FS_NODE_QUERY:       30 * time.Second,
+		fs.FS_GET_FILE_INFO:    30 * time.Second,
+	}
+}
+
+type cacheEntry struct {
+	method  string
+	arg     string
+	info    []byte
+	expires time.Time
+}
+
+// QueryCache keeps the results of read-only contract queries for a TTL set
+// per contract method, keyed by method and arguments. The Cores sharing a
+// QueryCache drop the entries affected by the transactions they submit once
+// they are confirmed, fail or time out: NodeUpdate drops the node, StoreFiles
+// the files stored, and so on. Transactions submitted elsewhere are only seen
+// once the entries expire. Cache hits still run through the Interceptors of a
+// Core.
+type QueryCache struct {
+	ttls map[string]time.Duration
+
+	lock    sync.Mutex
+	entries map[string]*cacheEntry
+	// generation counts the invalidations, so that a query started before
+	// one does not store its result after it.
+	generation uint64
+}
+
+// NewQueryCache returns a QueryCache caching the methods of ttls, one of the
+// fs.FS_GET_* or fs.FS_NODE_QUERY names, for their TTL. A nil ttls selects
+// DefaultQueryCacheTTLs.
+func NewQueryCache(ttls map[string]time.Duration) *QueryCache {
+	if ttls == nil {
+		ttls = DefaultQueryCacheTTLs()
+	}
+	q := &QueryCache{
+		ttls:    make(map[string]time.Duration, len(ttls)),
+		entries: make(map[string]*cacheEntry),
+	}
+	for method, ttl := range ttls {
+		if ttl > 0 {
+			q.ttls[method] = ttl
+		}
+	}
+	return q
+}
+
+// InvalidateNode drops the cached queries of the storage node at addr and the
+// node lists.
+func (q *QueryCache) InvalidateNode(addr ccom.Address) {
+	q.invalidate([]cacheRef{{fs.FS_NODE_QUERY, addr.ToBase58()}, {fs.FS_GET_NODE_LIST, ""}})
+}
+
+// InvalidateFile drops the cached queries of the file fileHash and the file
+// lists.
+func (q *QueryCache) InvalidateFile(fileHash string) {
+	q.invalidate(append(fileRefs(fileHash), cacheRef{fs.FS_GET_FILE_LIST, ""}))
+}
+
+// InvalidateMethod drops every cached query of method.
+func (q *QueryCache) InvalidateMethod(method string) {
+	q.invalidate([]cacheRef{{method, ""}})
+}
+
+// Purge drops every cached query.
+func (q *QueryCache) Purge() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.entries = make(map[string]*cacheEntry)
+	q.generation++
+}
+
+// cacheRef names the cached queries of method with argument arg, or all of
+// them when arg is empty.
+type cacheRef struct {
+	method string
+	arg    string
+}
+
+func (q *QueryCache) invalidate(refs []cacheRef) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for key, entry := range q.entries {
+		for _, ref := range refs {
+			if entry.method == ref.method && (ref.arg == "" || entry.arg == ref.arg) {
+				delete(q.entries, key)
+				break
+			}
+		}
+	}
+	q.generation++
+}
+
+// get returns a copy of the cached result of a query, and the generation to
+// pass to put when it is not cached.
+func (q *QueryCache) get(method string, params []interface{}) ([]byte, uint64, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := cacheKey(method, params)
+	entry, ok := q.entries[key]
+	if !ok {
+		return nil, q.generation, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(q.entries, key)
+		return nil, q.generation, false
+	}
+	return append([]byte(nil), entry.info...), q.generation, true
+}
+
+// put caches a copy of the result of a query run at generation, unless an
+// invalidation happened since.
+func (q *QueryCache) put(generation uint64, method string, params []interface{}, info []byte) {
+	ttl, ok := q.ttls[method]
+	if !ok {
+		return
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if generation != q.generation {
+		return
+	}
+	q.entries[cacheKey(method, params)] = &cacheEntry{
+		method:  method,
+		arg:     cacheArg(params),
+		info:    append([]byte(nil), info...),
+		expires: time.Now().Add(ttl),
+	}
+}
+
+func cacheKey(method string, params []interface{}) string {
+	return method + "\x00" + cacheArg(params)
+}
+
+// cacheArg identifies the arguments of a query: a node by its base58 address
+// and a file by its hash.
+func cacheArg(params []interface{}) string {
+	args := make([]string, len(params))
+	for i, param := range params {
+		switch v := param.(type) {
+		case ccom.Address:
+			args[i] = v.ToBase58()
+		case []byte:
+			args[i] = string(v)
+		default:
+			args[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return strings.Join(args, "\x00")
+}
+
+func fileRefs(fileHash string) []cacheRef {
+	return []cacheRef{{fs.FS_GET_FILE_INFO, fileHash}, {fs.FS_GET_PDP_INFO_LIST, fileHash}}
+}
+
+func nodeRefs(addr ccom.Address) []cacheRef {
+	return []cacheRef{{fs.FS_NODE_QUERY, addr.ToBase58()}, {fs.FS_GET_NODE_LIST, ""}}
+}
+
+// affectedQueries returns the queries whose result may change once a
+// transaction of method is confirmed, and false when they are not known.
+// params are the parameters of the transaction, nil when it was exported, and
+// batch lists the files of a batch file method.
+func affectedQueries(method string, params []interface{}, batch *BatchResult) ([]cacheRef, bool) {
+	var refs []cacheRef
+	switch method {
+	case fs.FS_NODE_REGISTER, fs.FS_NODE_UPDATE, fs.FS_NODE_CANCEL, fs.FS_NODE_WITH_DRAW_PROFIT:
+		if len(params) == 0 {
+			return []cacheRef{{fs.FS_NODE_QUERY, ""}, {fs.FS_GET_NODE_LIST, ""}}, true
+		}
+		switch v := params[0].(type) {
+		case *fs.FsNodeInfo:
+			return nodeRefs(v.NodeAddr), true
+		case ccom.Address:
+			return nodeRefs(v), true
+		}
+	case fs.FS_FILE_PROVE:
+		if len(params) == 0 {
+			break
+		}
+		if pdp, ok := params[0].(*fs.PdpData); ok {
+			return append(fileRefs(string(pdp.FileHash)), nodeRefs(pdp.NodeAddr)...), true
+		}
+	case fs.FS_STORE_FILES, fs.FS_RENEW_FILES, fs.FS_DELETE_FILES, fs.FS_TRANSFER_FILES:
+		if batch == nil {
+			break
+		}
+		for _, item := range batch.Items {
+			refs = append(refs, fileRefs(item.FileHash)...)
+		}
+		// the files change the pledged and rest volume of the nodes
+		return append(refs, cacheRef{fs.FS_GET_FILE_LIST, ""}, cacheRef{fs.FS_GET_SPACE_INFO, ""},
+			cacheRef{fs.FS_NODE_QUERY, ""}, cacheRef{fs.FS_GET_NODE_LIST, ""}), true
+	case fs.FS_READ_FILE_PLEDGE, fs.FS_CANCEL_FILE_READ:
+		return []cacheRef{{fs.FS_GET_READ_PLEDGE, ""}}, true
+	case fs.FS_READ_FILE_SETTLE:
+		refs = []cacheRef{{fs.FS_GET_READ_PLEDGE, ""}}
+		if len(params) != 0 {
+			if slice, ok := params[0].(*fs.FileReadSettleSlice); ok {
+				return append(refs, nodeRefs(slice.PayTo)...), true
+			}
+		}
+		return append(refs, cacheRef{fs.FS_NODE_QUERY, ""}, cacheRef{fs.FS_GET_NODE_LIST, ""}), true
+	case fs.FS_CREATE_SPACE, fs.FS_UPDATE_SPACE, fs.FS_DELETE_SPACE:
+		return []cacheRef{{fs.FS_GET_SPACE_INFO, ""}}, true
+	}
+	return nil, false
+}
+
+// invalidateCache drops the cached queries affected by a confirmed
+// transaction, or all of them when they are not known.
+func (c *Core) invalidateCache(pending *PendingTx) {
+	if c.Cache == nil {
+		return
+	}
+	refs, ok := affectedQueries(pending.method, pending.params, pending.batch)
+	if !ok {
+		c.Cache.Purge()
+		return
+	}
+	c.Cache.invalidate(refs)
+}
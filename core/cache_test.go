@@ -0,0 +1,93 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontfs-contract-api/sim"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+func TestQueryCache(t *testing.T) {
+	backend := &flakyBackend{Simulator: sim.NewSimulator(simConfig())}
+	c := newTestCore(t, backend)
+	c.Cache = core.NewQueryCache(nil)
+	intercepted := 0
+	c.Interceptors = []core.Interceptor{func(ctx context.Context, inv *core.Invocation, next core.Invoker) error {
+		if inv.Kind == core.InvocationQuery {
+			intercepted++
+		}
+		return next(ctx, inv)
+	}}
+
+	if _, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	backend.queries, intercepted = 0, 0
+	for i := 0; i < 3; i++ {
+		info, err := c.GetNodeInfo(c.WalletAddr)
+		if err != nil {
+			t.Fatalf("GetNodeInfo error: %s", err.Error())
+		}
+		if string(info.NodeNetAddr) != "tcp://127.0.0.1:3389" {
+			t.Fatalf("cached node info changed: %q", info.NodeNetAddr)
+		}
+		// the caller owns the result
+		info.NodeNetAddr[0] = 'x'
+	}
+	if backend.queries != 1 || intercepted != 3 {
+		t.Fatalf("expected 1 query and 3 intercepted, got %d and %d", backend.queries, intercepted)
+	}
+
+	// the confirmed update drops the node entry
+	if _, err := c.NodeUpdate(2*1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeUpdate error: %s", err.Error())
+	}
+	backend.queries = 0
+	info, err := c.GetNodeInfo(c.WalletAddr)
+	if err != nil {
+		t.Fatalf("GetNodeInfo error: %s", err.Error())
+	}
+	if info.Volume != 2*1024*1024 || backend.queries != 1 {
+		t.Fatalf("stale node info: volume %d, %d queries", info.Volume, backend.queries)
+	}
+
+	// a stored file drops the node entries too
+	if _, err = c.GetNodeInfoList(10); err != nil {
+		t.Fatalf("GetNodeInfoList error: %s", err.Error())
+	}
+	_, err, _ = c.StoreFiles([]common.FileStore{{
+		FileHash:       "CachedNodeFile",
+		FileBlockCount: 4,
+		CopyNumber:     1,
+		PdpInterval:    600,
+		TimeExpired:    1577836800 + 3*3600,
+		StorageType:    fs.FileStorageTypeUseFile,
+	}})
+	if err != nil {
+		t.Fatalf("StoreFiles error: %s", err.Error())
+	}
+	backend.queries = 0
+	if _, err = c.GetNodeInfo(c.WalletAddr); err != nil {
+		t.Fatalf("GetNodeInfo error: %s", err.Error())
+	}
+	if _, err = c.GetNodeInfoList(10); err != nil {
+		t.Fatalf("GetNodeInfoList error: %s", err.Error())
+	}
+	if backend.queries != 2 {
+		t.Fatalf("node entries kept after a store, %d queries", backend.queries)
+	}
+	backend.queries = 0
+
+	// queries without a TTL are not cached
+	for i := 0; i < 2; i++ {
+		if _, err = c.GetSpaceInfo(); err == nil {
+			t.Fatalf("GetSpaceInfo of no space succeeded")
+		}
+	}
+	if backend.queries != 2 {
+		t.Fatalf("expected 2 queries, got %d", backend.queries)
+	}
+}
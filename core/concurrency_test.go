@@ -0,0 +1,190 @@
+package core_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontfs-contract-api/sim"
+	ont "github.com/ontio/ontology-go-sdk"
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	ccom "github.com/ontio/ontology/common"
+)
+
+// fakeBackend confirms every transaction confirmAfter its submission and
+// counts the broadcasts of one account that overlap.
+type fakeBackend struct {
+	confirmAfter time.Duration
+
+	lock      sync.Mutex
+	inflight  map[ccom.Address]int
+	overlaps  int
+	submitted map[string]time.Time
+}
+
+func newFakeBackend(confirmAfter time.Duration) *fakeBackend {
+	return &fakeBackend{
+		confirmAfter: confirmAfter,
+		inflight:     make(map[ccom.Address]int),
+		submitted:    make(map[string]time.Time),
+	}
+}
+
+func (b *fakeBackend) InvokeNativeContract(gasPrice, gasLimit uint64, signer core.Signer, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (ccom.Uint256, error) {
+	b.lock.Lock()
+	b.inflight[signer.Address()]++
+	if b.inflight[signer.Address()] > 1 {
+		b.overlaps++
+	}
+	b.lock.Unlock()
+
+	time.Sleep(2 * time.Millisecond)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.inflight[signer.Address()]--
+	addr := signer.Address()
+	var nonce [8]byte
+	binary.LittleEndian.PutUint64(nonce[:], uint64(len(b.submitted)))
+	txHash := ccom.Uint256(sha256.Sum256(append(nonce[:], addr[:]...)))
+	b.submitted[txHash.ToHexString()] = time.Now()
+	return txHash, nil
+}
+
+func (b *fakeBackend) PreExecInvokeNativeContract(contractAddress ccom.Address, version byte, method string,
+	params []interface{}) (*sdkcom.PreExecResult, error) {
+	return nil, errors.New("fakeBackend does not pre-execute")
+}
+
+func (b *fakeBackend) GetBlockHeightByTxHash(txHash string) (uint32, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	submitted, ok := b.submitted[txHash]
+	if !ok {
+		return 0, errors.New("unknown transaction")
+	}
+	if time.Since(submitted) < b.confirmAfter {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (b *fakeBackend) GetSmartContractEvent(txHash string) (*sdkcom.SmartContactEvent, error) {
+	return &sdkcom.SmartContactEvent{TxHash: txHash, State: 1}, nil
+}
+
+func TestCore_ConcurrentSubmission(t *testing.T) {
+	backend := newFakeBackend(500 * time.Millisecond)
+	shared := ont.NewAccount()
+	c := core.InitWithBackend(backend, shared, 0, 20000)
+	// two views of one account are ordered against each other
+	cores := []*core.Core{
+		c,
+		c.WithAccount(shared),
+		c.WithAccount(ont.NewAccount()),
+	}
+
+	const perCore = 10
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, perCore*len(cores))
+	for _, c := range cores {
+		for i := 0; i < perCore; i++ {
+			wg.Add(1)
+			go func(c *core.Core) {
+				defer wg.Done()
+				_, err := c.NodeUpdateContext(context.Background(), 1024*1024, 1577836800+100000, 600,
+					"tcp://127.0.0.1:3389")
+				errs <- err
+			}(c)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("NodeUpdateContext error: %s", err.Error())
+		}
+	}
+
+	if backend.overlaps != 0 {
+		t.Fatalf("%d broadcasts of one account overlapped", backend.overlaps)
+	}
+	if len(backend.submitted) != perCore*len(cores) {
+		t.Fatalf("%d transactions submitted", len(backend.submitted))
+	}
+	// one second per confirmation poll; serialized confirmations would take
+	// at least 30s
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("confirmations did not overlap, took %s", elapsed)
+	}
+}
+
+func TestCore_QueuedSubmissionCancelled(t *testing.T) {
+	backend := newFakeBackend(0)
+	c := core.InitWithBackend(backend, ont.NewAccount(), 0, 20000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.NodeCancelAsync(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("NodeCancelAsync error: %v", err)
+	}
+	if _, err := c.NodeCancel(); err != nil {
+		t.Fatalf("NodeCancel after a cancelled submission error: %s", err.Error())
+	}
+}
+
+func TestCore_ConcurrentSubmissionTracked(t *testing.T) {
+	chain := sim.NewSimulator(simConfig())
+	// nothing listens there, so the tracker polls the simulator
+	c, err := core.New(core.WithBackend(chain), core.WithGasLimit(20000),
+		core.WithConfirmTracker("ws://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("New error: %s", err.Error())
+	}
+	defer c.Close()
+	if c.Tracker == nil {
+		t.Fatalf("no tracker started")
+	}
+
+	const accounts = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, accounts*2)
+	for i := 0; i < accounts; i++ {
+		view := c.WithAccount(ont.NewAccount())
+		for _, submit := range []func() error{
+			func() error {
+				_, err := view.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389")
+				return err
+			},
+			func() error {
+				_, err := view.CreateSpace(1024, 1, 600, 1577836800+100000)
+				return err
+			},
+		} {
+			wg.Add(1)
+			go func(submit func() error) {
+				defer wg.Done()
+				errs <- submit()
+			}(submit)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent submission error: %s", err.Error())
+		}
+	}
+
+	list, err := c.GetNodeInfoList(accounts * 2)
+	if err != nil || len(list.NodesInfo) != accounts {
+		t.Fatalf("GetNodeInfoList %+v, error %v", list, err)
+	}
+}
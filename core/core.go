@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -12,16 +13,18 @@ import (
 	ont "github.com/ontio/ontology-go-sdk"
 	ccom "github.com/ontio/ontology/common"
 	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
-	"github.com/ontio/ontology/smartcontract/service/native/utils"
-	"strings"
 )
 
-const contractVersion = byte(0)
 const defaultMinPdpInterval = uint64(10 * 60)
-
-var contractAddr ccom.Address
-var contractAddrStr string
-
+const defaultBatchGasPerFile = uint64(200)
+const defaultBatchMaxBytes = 256 * 1024
+
+// Core is safe for concurrent use once its fields are set: the methods do not
+// modify them. Setting a field, StartConfirmTracker included, must therefore
+// happen before c is shared. The transactions of each signing account are
+// signed and broadcast one at a time through a Core and the views derived
+// from it, while their confirmations are awaited concurrently. Separate Cores
+// do not order their transactions against each other.
 type Core struct {
 	WalletPath    string
 	Password      []byte
@@ -29,73 +32,135 @@ type Core struct {
 	GasPrice      uint64
 	GasLimit      uint64
 	OntSdk        *ont.OntologySdk
+	Backend       ChainBackend
+	Tracker       *ConfirmTracker
 	Wallet        *ont.Wallet
 	DefAcc        *ont.Account
 	OntRpcSrvAddr string
+	// Signer, when set, signs in place of DefAcc.
+	Signer Signer
+	// Payer, when set, co-signs every transaction and pays its gas, as well
+	// as the fees of UpdateSpace and RenewFiles. PayerAddr names a payer whose
+	// key is held elsewhere: the transactions can then only be exported for
+	// co-signing, see Export.
+	Payer     Signer
+	PayerAddr ccom.Address
+	// ContractAddr and ContractVersion select the ontfs contract deployment,
+	// the native contract when ContractAddr is empty.
+	ContractAddr    ccom.Address
+	ContractVersion byte
+	// ConfirmTimeout bounds the wait for a submitted transaction, which is
+	// TX_CONFIRM_TIMEOUT seconds when zero.
+	ConfirmTimeout time.Duration
+	// Logger receives the log of the Core, which goes to DefaultLogger when
+	// it is nil.
+	Logger Logger
+	// Retry is applied to the calls to the node that are safe to repeat.
+	Retry RetryPolicy
+	// Metrics, when set, observes every call to the contract.
+	Metrics Metrics
+	// Interceptors wrap every query and transaction submission, the first
+	// one being the outermost.
+	Interceptors []Interceptor
+	// PlacementCheck makes StoreFiles check with PlanPlacement that the
+	// registered nodes can hold the copies of every file before submitting.
+	PlacementCheck bool
+	// Cache, when set, keeps the results of the queries it is configured
+	// for. The views of c share it.
+	Cache *QueryCache
+
+	// BatchGasPerFile and BatchMaxBytes bound the number of files of a batch
+	// file method put in one transaction: at most GasLimit/BatchGasPerFile
+	// files, serialized in at most BatchMaxBytes. Zero selects the defaults.
+	// Under GasEstimation, a transaction whose gas exceeds GasLimit is split
+	// further.
+	BatchGasPerFile uint64
+	BatchMaxBytes   int
+	// BatchParallelism is the number of transactions of one batch submitted
+	// at a time. Zero or one submits them one after another.
+	BatchParallelism int
+
+	// When GasEstimation is set, every transaction is pre-executed first to
+	// measure its gas, and is submitted with that gas plus GasMarginPercent
+	// (20 when zero) as its limit. Otherwise, or when the Backend is not an
+	// EstimateBackend, it is submitted with GasLimit.
+	GasEstimation    bool
+	GasMarginPercent uint64
+
+	accounts *accountCache
+	queues   *submitQueues
 }
 
+// Init creates a Core bound to the default account of the wallet at
+// walletPath, or a query-only Core when walletPath is empty. It logs the
+// error to DefaultLogger and returns nil when the wallet cannot be opened;
+// use New to get the error instead.
 func Init(walletPath string, walletPwd string, ontRpcSrvAddr string, gasPrice uint64, gasLimit uint64) *Core {
-	contractAddr = utils.OntFSContractAddress
-	contractAddrStr = contractAddr.ToHexString()
+	ontFs, err := New(
+		WithWallet(walletPath),
+		WithPassword([]byte(walletPwd)),
+		WithRPCAddress(ontRpcSrvAddr),
+		WithGasPrice(gasPrice),
+		WithGasLimit(gasLimit),
+	)
+	if err != nil {
+		DefaultLogger().Error("init error", ErrField(err))
+		return nil
+	}
+	return ontFs
+}
 
+// InitWithBackend creates a Core that talks to the chain through backend
+// instead of an RPC client. acc may be nil for a query-only Core.
+func InitWithBackend(backend ChainBackend, acc *ont.Account, gasPrice uint64, gasLimit uint64) *Core {
 	ontFs := &Core{
-		WalletPath:    walletPath,
-		Password:      []byte(walletPwd),
-		GasPrice:      gasPrice,
-		GasLimit:      gasLimit,
-		OntRpcSrvAddr: ontRpcSrvAddr,
+		GasPrice: gasPrice,
+		GasLimit: gasLimit,
+		Backend:  backend,
+		DefAcc:   acc,
+		queues:   newSubmitQueues(),
 	}
-
-	ontFs.OntSdk = ont.NewOntologySdk()
-	ontFs.OntSdk.NewRpcClient().SetAddress(ontFs.OntRpcSrvAddr)
-
-	if len(walletPath) != 0 {
-		var err error
-		ontFs.Wallet, err = ontFs.OntSdk.OpenWallet(ontFs.WalletPath)
-		if err != nil {
-			fmt.Printf("Account.Open error:%s\n", err)
-			return nil
-		}
-		ontFs.DefAcc, err = ontFs.Wallet.GetDefaultAccount(ontFs.Password)
-		if err != nil {
-			fmt.Printf("GetDefaultAccount error:%s\n", err)
-			return nil
-		}
-		ontFs.WalletAddr = ontFs.DefAcc.Address
-	} else {
-		ontFs.Wallet = nil
-		ontFs.DefAcc = nil
+	if acc != nil {
+		ontFs.WalletAddr = acc.Address
 	}
 	return ontFs
 }
 
 func (c *Core) GetGlobalParam() (*fs.FsGlobalParam, error) {
-	ret, err := c.OntSdk.Native.PreExecInvokeNativeContract(contractAddr, contractVersion,
-		fs.FS_GET_GLOBAL_PARAM, []interface{}{})
+	return c.GetGlobalParamContext(context.Background())
+}
+
+func (c *Core) GetGlobalParamContext(ctx context.Context) (*fs.FsGlobalParam, error) {
+	info, err := c.preExec(ctx, "GetGlobalParam", fs.FS_GET_GLOBAL_PARAM, []interface{}{})
 	if err != nil {
 		return nil, err
 	}
-	data, err := ret.Result.ToByteArray()
-	if err != nil {
-		return nil, fmt.Errorf("GetGlobalParam result toByteArray: %s", err.Error())
-	}
 
 	var globalParam fs.FsGlobalParam
-	retInfo := fs.DecRet(data)
-	if retInfo.Ret {
-		src := ccom.NewZeroCopySource(retInfo.Info)
-		if err = globalParam.Deserialization(src); err != nil {
-			return nil, fmt.Errorf("GetGlobalParam error: %s", err.Error())
-		}
-		return &globalParam, nil
-	} else {
-		return nil, errors.New(string(retInfo.Info))
+	src := ccom.NewZeroCopySource(info)
+	if err = globalParam.Deserialization(src); err != nil {
+		return nil, fmt.Errorf("GetGlobalParam error: %s", err.Error())
 	}
+	return &globalParam, nil
 }
 
 func (c *Core) NodeRegister(volume uint64, serviceTime uint64, minPdpInterval uint64, nodeNetAddr string) ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("NodeRegister DefAcc is nil")
+	return c.NodeRegisterContext(context.Background(), volume, serviceTime, minPdpInterval, nodeNetAddr)
+}
+
+func (c *Core) NodeRegisterContext(ctx context.Context, volume uint64, serviceTime uint64, minPdpInterval uint64,
+	nodeNetAddr string) ([]byte, error) {
+	pending, err := c.NodeRegisterAsync(ctx, volume, serviceTime, minPdpInterval, nodeNetAddr)
+	if err != nil {
+		return nil, err
+	}
+	return pending.result(ctx)
+}
+
+func (c *Core) NodeRegisterAsync(ctx context.Context, volume uint64, serviceTime uint64, minPdpInterval uint64,
+	nodeNetAddr string) (*PendingTx, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_NODE_REGISTER}
 	}
 	fsNodeInfo := fs.FsNodeInfo{
 		Pledge:         0,
@@ -104,50 +169,38 @@ func (c *Core) NodeRegister(volume uint64, serviceTime uint64, minPdpInterval ui
 		RestVol:        0,
 		ServiceTime:    serviceTime,
 		MinPdpInterval: minPdpInterval,
-		NodeAddr:       c.WalletAddr,
+		NodeAddr:       c.signer().Address(),
 		NodeNetAddr:    []byte(nodeNetAddr),
 	}
+	return c.invokeAsync(ctx, fs.FS_NODE_REGISTER, []interface{}{&fsNodeInfo}, nil)
+}
 
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_NODE_REGISTER, []interface{}{&fsNodeInfo})
-	if err != nil {
-		return nil, err
-	}
+func (c *Core) NodeQuery(nodeWallet ccom.Address) (*fs.FsNodeInfo, error) {
+	return c.NodeQueryContext(context.Background(), nodeWallet)
+}
 
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("NodeRegister tx is not confirmed")
-	}
-	return txHash.ToArray(), nil
+// NodeQueryContext is GetNodeInfoContext.
+func (c *Core) NodeQueryContext(ctx context.Context, nodeWallet ccom.Address) (*fs.FsNodeInfo, error) {
+	return c.GetNodeInfoContext(ctx, nodeWallet)
 }
 
-func (c *Core) NodeQuery(nodeWallet ccom.Address) (*fs.FsNodeInfo, error) {
-	ret, err := c.OntSdk.Native.PreExecInvokeNativeContract(contractAddr, contractVersion,
-		fs.FS_NODE_QUERY, []interface{}{nodeWallet})
+func (c *Core) NodeUpdate(volume uint64, serviceTime uint64, minPdpInterval uint64, nodeNetAddr string) ([]byte, error) {
+	return c.NodeUpdateContext(context.Background(), volume, serviceTime, minPdpInterval, nodeNetAddr)
+}
+
+func (c *Core) NodeUpdateContext(ctx context.Context, volume uint64, serviceTime uint64, minPdpInterval uint64,
+	nodeNetAddr string) ([]byte, error) {
+	pending, err := c.NodeUpdateAsync(ctx, volume, serviceTime, minPdpInterval, nodeNetAddr)
 	if err != nil {
 		return nil, err
 	}
-	data, err := ret.Result.ToByteArray()
-	if err != nil {
-		return nil, fmt.Errorf("NodeQuery result toByteArray: %s", err.Error())
-	}
-
-	var fsNodeInfo fs.FsNodeInfo
-	retInfo := fs.DecRet(data)
-	if retInfo.Ret {
-		src := ccom.NewZeroCopySource(retInfo.Info)
-		if err = fsNodeInfo.Deserialization(src); err != nil {
-			return nil, fmt.Errorf("NodeQuery error: %s", err.Error())
-		}
-		return &fsNodeInfo, nil
-	} else {
-		return nil, errors.New(string(retInfo.Info))
-	}
+	return pending.result(ctx)
 }
 
-func (c *Core) NodeUpdate(volume uint64, serviceTime uint64, minPdpInterval uint64, nodeNetAddr string) ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("NodeUpdate DefAcc is nil")
+func (c *Core) NodeUpdateAsync(ctx context.Context, volume uint64, serviceTime uint64, minPdpInterval uint64,
+	nodeNetAddr string) (*PendingTx, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_NODE_UPDATE}
 	}
 	fsNodeInfo := fs.FsNodeInfo{
 		Pledge:         0,
@@ -156,129 +209,122 @@ func (c *Core) NodeUpdate(volume uint64, serviceTime uint64, minPdpInterval uint
 		RestVol:        0,
 		ServiceTime:    serviceTime,
 		MinPdpInterval: minPdpInterval,
-		NodeAddr:       c.WalletAddr,
+		NodeAddr:       c.signer().Address(),
 		NodeNetAddr:    []byte(nodeNetAddr),
 	}
-
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_NODE_UPDATE, []interface{}{&fsNodeInfo},
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("NodeUpdate tx is not confirmed")
-	}
-	return txHash.ToArray(), nil
+	return c.invokeAsync(ctx, fs.FS_NODE_UPDATE, []interface{}{&fsNodeInfo}, nil)
 }
 
 func (c *Core) NodeCancel() ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("NodeCancel DefAcc is nil")
-	}
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_NODE_CANCEL, []interface{}{c.WalletAddr})
+	return c.NodeCancelContext(context.Background())
+}
+
+func (c *Core) NodeCancelContext(ctx context.Context) ([]byte, error) {
+	pending, err := c.NodeCancelAsync(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return pending.result(ctx)
+}
 
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("NodeCancel tx is not confirmed")
+func (c *Core) NodeCancelAsync(ctx context.Context) (*PendingTx, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_NODE_CANCEL}
 	}
-	return txHash.ToArray(), nil
+	return c.invokeAsync(ctx, fs.FS_NODE_CANCEL, []interface{}{c.signer().Address()}, nil)
 }
 
 func (c *Core) NodeWithDrawProfit() ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("NodeWithDrawProfit DefAcc is nil")
-	}
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_NODE_WITH_DRAW_PROFIT, []interface{}{c.WalletAddr},
-	)
+	return c.NodeWithDrawProfitContext(context.Background())
+}
+
+func (c *Core) NodeWithDrawProfitContext(ctx context.Context) ([]byte, error) {
+	pending, err := c.NodeWithDrawProfitAsync(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return pending.result(ctx)
+}
 
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("NodeWithDrawProfit tx is not confirmed")
+func (c *Core) NodeWithDrawProfitAsync(ctx context.Context) (*PendingTx, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_NODE_WITH_DRAW_PROFIT}
 	}
-	return txHash.ToArray(), nil
+	return c.invokeAsync(ctx, fs.FS_NODE_WITH_DRAW_PROFIT, []interface{}{c.signer().Address()},
+		nil)
 }
 
 func (c *Core) FileProve(fileHashStr string, proveData []byte, blockHeight uint64) ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil")
-	}
-	fileHash := []byte(fileHashStr)
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_FILE_PROVE, []interface{}{&fs.PdpData{
-			FileHash:        fileHash,
-			NodeAddr:        c.WalletAddr,
-			ProveData:       proveData,
-			ChallengeHeight: blockHeight,
-		}},
-	)
+	return c.FileProveContext(context.Background(), fileHashStr, proveData, blockHeight)
+}
+
+func (c *Core) FileProveContext(ctx context.Context, fileHashStr string, proveData []byte,
+	blockHeight uint64) ([]byte, error) {
+	pending, err := c.FileProveAsync(ctx, fileHashStr, proveData, blockHeight)
 	if err != nil {
 		return nil, err
 	}
+	return pending.result(ctx)
+}
 
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("FileProve tx is not confirmed")
+func (c *Core) FileProveAsync(ctx context.Context, fileHashStr string, proveData []byte,
+	blockHeight uint64) (*PendingTx, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_FILE_PROVE}
 	}
-	return txHash.ToArray(), nil
+	fileHash := []byte(fileHashStr)
+	return c.invokeAsync(ctx, fs.FS_FILE_PROVE, []interface{}{&fs.PdpData{
+		FileHash:        fileHash,
+		NodeAddr:        c.signer().Address(),
+		ProveData:       proveData,
+		ChallengeHeight: blockHeight,
+	}}, nil)
 }
 
 func (c *Core) GetFileReadPledge(fileHashStr string, downloader ccom.Address) (*fs.ReadPledge, error) {
+	return c.GetFileReadPledgeContext(context.Background(), fileHashStr, downloader)
+}
+
+func (c *Core) GetFileReadPledgeContext(ctx context.Context, fileHashStr string,
+	downloader ccom.Address) (*fs.ReadPledge, error) {
 	fileHash := []byte(fileHashStr)
 	getReadPledge := &fs.GetReadPledge{
 		FileHash:   fileHash,
 		Downloader: downloader,
 	}
-	ret, err := c.OntSdk.Native.PreExecInvokeNativeContract(contractAddr, contractVersion,
-		fs.FS_GET_READ_PLEDGE, []interface{}{getReadPledge})
+	info, err := c.preExec(ctx, "GetFileReadPledge", fs.FS_GET_READ_PLEDGE, []interface{}{getReadPledge})
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := ret.Result.ToByteArray()
-	if err != nil {
-		return nil, fmt.Errorf("GetFileReadPledge result toByteArray: %s", err.Error())
-	}
-
 	var readPledge fs.ReadPledge
-	retInfo := fs.DecRet(data)
-	if retInfo.Ret {
-		src := ccom.NewZeroCopySource(retInfo.Info)
-		if err = readPledge.Deserialization(src); err != nil {
-			return nil, err
-		}
-		return &readPledge, nil
-	} else {
-		return nil, errors.New(string(retInfo.Info))
+	src := ccom.NewZeroCopySource(info)
+	if err = readPledge.Deserialization(src); err != nil {
+		return nil, err
 	}
+	return &readPledge, nil
 }
 
 func (c *Core) FileReadProfitSettle(fileReadSettleSlice *fs.FileReadSettleSlice) ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("FileReadProfitSettle DefAcc is nil")
-	}
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_READ_FILE_SETTLE, []interface{}{fileReadSettleSlice},
-	)
+	return c.FileReadProfitSettleContext(context.Background(), fileReadSettleSlice)
+}
+
+func (c *Core) FileReadProfitSettleContext(ctx context.Context,
+	fileReadSettleSlice *fs.FileReadSettleSlice) ([]byte, error) {
+	pending, err := c.FileReadProfitSettleAsync(ctx, fileReadSettleSlice)
 	if err != nil {
 		return nil, err
 	}
+	return pending.result(ctx)
+}
 
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("FileReadProfitSettle tx is not confirmed")
+func (c *Core) FileReadProfitSettleAsync(ctx context.Context,
+	fileReadSettleSlice *fs.FileReadSettleSlice) (*PendingTx, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_READ_FILE_SETTLE}
 	}
-	return txHash.ToArray(), nil
+	return c.invokeAsync(ctx, fs.FS_READ_FILE_SETTLE,
+		[]interface{}{fileReadSettleSlice}, nil)
 }
 
 func (c *Core) VerifyFileReadSettleSlice(settleSlice *fs.FileReadSettleSlice) (bool, error) {
@@ -305,82 +351,86 @@ func (c *Core) VerifyFileReadSettleSlice(settleSlice *fs.FileReadSettleSlice) (b
 }
 
 func (c *Core) GetFilePdpRecordList(fileHashStr string) (*fs.PdpRecordList, error) {
+	return c.GetFilePdpRecordListContext(context.Background(), fileHashStr)
+}
+
+func (c *Core) GetFilePdpRecordListContext(ctx context.Context, fileHashStr string) (*fs.PdpRecordList, error) {
 	fileHash := []byte(fileHashStr)
-	ret, err := c.OntSdk.Native.PreExecInvokeNativeContract(contractAddr, contractVersion,
-		fs.FS_GET_PDP_INFO_LIST, []interface{}{fileHash},
-	)
+	info, err := c.preExec(ctx, "GetFilePdpRecordList", fs.FS_GET_PDP_INFO_LIST, []interface{}{fileHash})
 	if err != nil {
 		return nil, err
 	}
-	data, err := ret.Result.ToByteArray()
-	if err != nil {
-		return nil, fmt.Errorf("GetFilePdpRecordList result toByteArray: %s", err.Error())
-	}
 
 	var pdpRecordList fs.PdpRecordList
-	retInfo := fs.DecRet(data)
-	if retInfo.Ret {
-		src := ccom.NewZeroCopySource(retInfo.Info)
-		if err = pdpRecordList.Deserialization(src); err != nil {
-			return nil, fmt.Errorf("GetFilePdpRecordList deserialize error: %s", err.Error())
-		}
-		return &pdpRecordList, err
-	} else {
-		return nil, errors.New(string(retInfo.Info))
+	src := ccom.NewZeroCopySource(info)
+	if err = pdpRecordList.Deserialization(src); err != nil {
+		return nil, fmt.Errorf("GetFilePdpRecordList deserialize error: %s", err.Error())
 	}
+	return &pdpRecordList, nil
 }
 
 func (c *Core) GetNodeInfo(nodeWallet ccom.Address) (*fs.FsNodeInfo, error) {
-	ret, err := c.OntSdk.Native.PreExecInvokeNativeContract(contractAddr, contractVersion,
-		fs.FS_NODE_QUERY, []interface{}{nodeWallet})
+	return c.GetNodeInfoContext(context.Background(), nodeWallet)
+}
+
+func (c *Core) GetNodeInfoContext(ctx context.Context, nodeWallet ccom.Address) (*fs.FsNodeInfo, error) {
+	info, err := c.preExec(ctx, "GetNodeInfo", fs.FS_NODE_QUERY, []interface{}{nodeWallet})
 	if err != nil {
 		return nil, err
 	}
-	data, err := ret.Result.ToByteArray()
-	if err != nil {
-		return nil, fmt.Errorf("GetNodeInfo result toByteArray: %s", err.Error())
-	}
 
 	var fsNodeInfo fs.FsNodeInfo
-	retInfo := fs.DecRet(data)
-	if retInfo.Ret {
-		src := ccom.NewZeroCopySource(retInfo.Info)
-		if err = fsNodeInfo.Deserialization(src); err != nil {
-			return nil, fmt.Errorf("GetNodeInfo error: %s", err.Error())
-		}
-		return &fsNodeInfo, nil
-	} else {
-		return nil, errors.New(string(retInfo.Info))
+	src := ccom.NewZeroCopySource(info)
+	if err = fsNodeInfo.Deserialization(src); err != nil {
+		return nil, fmt.Errorf("GetNodeInfo error: %s", err.Error())
 	}
+	return &fsNodeInfo, nil
 }
 
 func (c *Core) GetNodeInfoList(count uint64) (*fs.FsNodeInfoList, error) {
-	ret, err := c.OntSdk.Native.PreExecInvokeNativeContract(contractAddr, contractVersion,
-		fs.FS_GET_NODE_LIST, []interface{}{count})
+	return c.GetNodeInfoListContext(context.Background(), count)
+}
+
+func (c *Core) GetNodeInfoListContext(ctx context.Context, count uint64) (*fs.FsNodeInfoList, error) {
+	info, err := c.preExec(ctx, "GetNodeInfoList", fs.FS_GET_NODE_LIST, []interface{}{count})
 	if err != nil {
 		return nil, err
 	}
-	data, err := ret.Result.ToByteArray()
-	if err != nil {
-		return nil, fmt.Errorf("GetNodeInfoList result toByteArray: %s", err.Error())
-	}
 
 	var nodeInfoList fs.FsNodeInfoList
-	retInfo := fs.DecRet(data)
-	if retInfo.Ret {
-		src := ccom.NewZeroCopySource(retInfo.Info)
-		if err = nodeInfoList.Deserialization(src); err != nil {
-			return nil, fmt.Errorf("GetNodeInfoList Deserialization: %s", err.Error())
-		}
-		return &nodeInfoList, nil
-	} else {
-		return nil, errors.New(string(retInfo.Info))
+	src := ccom.NewZeroCopySource(info)
+	if err = nodeInfoList.Deserialization(src); err != nil {
+		return nil, fmt.Errorf("GetNodeInfoList Deserialization: %s", err.Error())
 	}
+	return &nodeInfoList, nil
 }
 
 func (c *Core) CreateSpace(volume uint64, copyNumber uint64, pdpInterval uint64, timeExpired uint64) ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil")
+	return c.CreateSpaceContext(context.Background(), volume, copyNumber, pdpInterval, timeExpired)
+}
+
+func (c *Core) CreateSpaceContext(ctx context.Context, volume uint64, copyNumber uint64, pdpInterval uint64,
+	timeExpired uint64) ([]byte, error) {
+	pending, err := c.CreateSpaceAsync(ctx, volume, copyNumber, pdpInterval, timeExpired)
+	if err != nil {
+		return nil, err
+	}
+	return pending.result(ctx)
+}
+
+func (c *Core) CreateSpaceAsync(ctx context.Context, volume uint64, copyNumber uint64, pdpInterval uint64,
+	timeExpired uint64) (*PendingTx, error) {
+	param, err := c.createSpaceParam(volume, copyNumber, pdpInterval, timeExpired)
+	if err != nil {
+		return nil, err
+	}
+	return c.invokeAsync(ctx, fs.FS_CREATE_SPACE, []interface{}{param}, nil)
+}
+
+func (c *Core) createSpaceParam(volume uint64, copyNumber uint64, pdpInterval uint64,
+	timeExpired uint64) ([]byte, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_CREATE_SPACE}
 	}
 
 	if pdpInterval < defaultMinPdpInterval {
@@ -388,7 +438,7 @@ func (c *Core) CreateSpace(volume uint64, copyNumber uint64, pdpInterval uint64,
 	}
 
 	spaceInfo := fs.SpaceInfo{
-		SpaceOwner:  c.DefAcc.Address,
+		SpaceOwner:  c.signer().Address(),
 		Volume:      volume,
 		CopyNumber:  copyNumber,
 		PdpInterval: pdpInterval,
@@ -397,99 +447,134 @@ func (c *Core) CreateSpace(volume uint64, copyNumber uint64, pdpInterval uint64,
 
 	sink := ccom.NewZeroCopySink(nil)
 	spaceInfo.Serialization(sink)
+	return sink.Bytes(), nil
+}
 
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion,
-		contractAddr, fs.FS_CREATE_SPACE, []interface{}{sink.Bytes()})
+func (c *Core) GetSpaceInfo() (*fs.SpaceInfo, error) {
+	return c.GetSpaceInfoContext(context.Background())
+}
+
+func (c *Core) GetSpaceInfoContext(ctx context.Context) (*fs.SpaceInfo, error) {
+	info, err := c.preExec(ctx, "GetSpaceInfo", fs.FS_GET_SPACE_INFO, []interface{}{c.accountAddress()})
 	if err != nil {
 		return nil, err
 	}
 
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("CreateSpace tx is not confirmed")
+	var spaceInfo fs.SpaceInfo
+	src := ccom.NewZeroCopySource(info)
+	if err = spaceInfo.Deserialization(src); err != nil {
+		return nil, fmt.Errorf("GetSpaceInfo Deserialization: %s", err.Error())
 	}
-	return txHash.ToArray(), nil
+	return &spaceInfo, nil
 }
 
-func (c *Core) GetSpaceInfo() (*fs.SpaceInfo, error) {
-	ret, err := c.OntSdk.Native.PreExecInvokeNativeContract(contractAddr, contractVersion,
-		fs.FS_GET_SPACE_INFO, []interface{}{c.WalletAddr})
+func (c *Core) UpdateSpace(volume uint64, timeExpired uint64) ([]byte, error) {
+	return c.UpdateSpaceContext(context.Background(), volume, timeExpired)
+}
+
+func (c *Core) UpdateSpaceContext(ctx context.Context, volume uint64, timeExpired uint64) ([]byte, error) {
+	pending, err := c.UpdateSpaceAsync(ctx, volume, timeExpired)
 	if err != nil {
 		return nil, err
 	}
-	data, err := ret.Result.ToByteArray()
-	if err != nil {
-		return nil, fmt.Errorf("GetNodeInfoList result toByteArray: %s", err.Error())
-	}
+	return pending.result(ctx)
+}
 
-	var spaceInfo fs.SpaceInfo
-	retInfo := fs.DecRet(data)
-	if retInfo.Ret {
-		src := ccom.NewZeroCopySource(retInfo.Info)
-		if err = spaceInfo.Deserialization(src); err != nil {
-			return nil, fmt.Errorf("GetSpaceInfo Deserialization: %s", err.Error())
-		}
-		return &spaceInfo, nil
-	} else {
-		return nil, errors.New(string(retInfo.Info))
+func (c *Core) UpdateSpaceAsync(ctx context.Context, volume uint64, timeExpired uint64) (*PendingTx, error) {
+	param, err := c.updateSpaceParam(volume, timeExpired)
+	if err != nil {
+		return nil, err
 	}
+	return c.invokeAsync(ctx, fs.FS_UPDATE_SPACE, []interface{}{param}, nil)
 }
 
-func (c *Core) UpdateSpace(volume uint64, timeExpired uint64) ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil")
+func (c *Core) updateSpaceParam(volume uint64, timeExpired uint64) ([]byte, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_UPDATE_SPACE}
 	}
 
 	spaceUpdate := fs.SpaceUpdate{
-		SpaceOwner:     c.DefAcc.Address,
-		Payer:          c.DefAcc.Address,
+		SpaceOwner:     c.signer().Address(),
+		Payer:          c.payerAddress(),
 		NewVolume:      volume,
 		NewTimeExpired: timeExpired,
 	}
 
 	sink := ccom.NewZeroCopySink(nil)
 	spaceUpdate.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func (c *Core) DeleteSpace() ([]byte, error) {
+	return c.DeleteSpaceContext(context.Background())
+}
 
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion,
-		contractAddr, fs.FS_UPDATE_SPACE, []interface{}{sink.Bytes()})
+func (c *Core) DeleteSpaceContext(ctx context.Context) ([]byte, error) {
+	pending, err := c.DeleteSpaceAsync(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("UpdateSpace tx is not confirmed")
-	}
-	return txHash.ToArray(), nil
+	return pending.result(ctx)
 }
 
-func (c *Core) DeleteSpace() ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil")
+func (c *Core) DeleteSpaceAsync(ctx context.Context) (*PendingTx, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_DELETE_SPACE}
 	}
+	return c.invokeAsync(ctx, fs.FS_DELETE_SPACE, []interface{}{c.signer().Address()}, nil)
+}
 
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_DELETE_SPACE, []interface{}{c.DefAcc.Address})
-	if err != nil {
-		return nil, err
+// chainTime returns the timestamp of the current block, or the local time
+// when the Backend cannot report it.
+func (c *Core) chainTime(ctx context.Context) (uint64, error) {
+	blockBackend, ok := c.Backend.(BlockBackend)
+	if !ok {
+		return uint64(time.Now().Unix()), nil
 	}
-
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("DeleteSpace tx is not confirmed")
+	timeBackend, ok := c.Backend.(BlockTimeBackend)
+	if !ok {
+		return uint64(time.Now().Unix()), nil
 	}
-	return txHash.ToArray(), nil
+	var timestamp uint32
+	err := c.read(ctx, "GetBlockTimestamp", func() error {
+		height, err := blockBackend.GetCurrentBlockHeight()
+		if err != nil {
+			return fmt.Errorf("GetCurrentBlockHeight error: %s", err.Error())
+		}
+		timestamp, err = timeBackend.GetBlockTimestamp(height)
+		if err != nil {
+			return fmt.Errorf("GetBlockTimestamp error: %s", err.Error())
+		}
+		return nil
+	})
+	return uint64(timestamp), err
 }
 
 func (c *Core) GetFileList() (*fs.FileHashList, error) {
-	height, err := c.OntSdk.GetCurrentBlockHeight()
-	if err != nil {
-		return nil, fmt.Errorf("GenPassport GetCurrentBlockHeight error: %s", err.Error())
-	}
+	return c.GetFileListContext(context.Background())
+}
 
-	blockHash, err := c.OntSdk.GetBlockHash(height)
+func (c *Core) GetFileListContext(ctx context.Context) (*fs.FileHashList, error) {
+	blockBackend, ok := c.Backend.(BlockBackend)
+	if !ok {
+		return nil, errors.New("GetFileList backend does not provide block info")
+	}
+	var height uint32
+	var blockHash ccom.Uint256
+	err := c.read(ctx, fs.FS_GET_FILE_LIST, func() error {
+		var err error
+		height, err = blockBackend.GetCurrentBlockHeight()
+		if err != nil {
+			return fmt.Errorf("GenPassport GetCurrentBlockHeight error: %s", err.Error())
+		}
+		blockHash, err = blockBackend.GetBlockHash(height)
+		if err != nil {
+			return fmt.Errorf("GenPassport GetBlockHash error: %s", err.Error())
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("GenPassport GetBlockHash error: %s", err.Error())
+		return nil, err
 	}
 
 	passport, err := c.GenPassport(height, blockHash.ToArray())
@@ -497,68 +582,89 @@ func (c *Core) GetFileList() (*fs.FileHashList, error) {
 		return nil, fmt.Errorf("GetFileList genPassport error: %s", err.Error())
 	}
 
-	ret, err := c.OntSdk.Native.PreExecInvokeNativeContract(contractAddr, contractVersion,
-		fs.FS_GET_FILE_LIST, []interface{}{passport})
+	info, err := c.preExec(ctx, "GetFileList", fs.FS_GET_FILE_LIST, []interface{}{passport})
 	if err != nil {
 		return nil, err
 	}
-	data, err := ret.Result.ToByteArray()
-	if err != nil {
-		return nil, fmt.Errorf("GetFileList result toByteArray: %s", err.Error())
-	}
 
 	var fileList fs.FileHashList
-	retInfo := fs.DecRet(data)
-	if retInfo.Ret {
-		src := ccom.NewZeroCopySource(retInfo.Info)
-		if err = fileList.Deserialization(src); err != nil {
-			return nil, fmt.Errorf("GetFileList error: %s", err.Error())
-		}
-		return &fileList, nil
-	} else {
-		return nil, errors.New(string(retInfo.Info))
+	src := ccom.NewZeroCopySource(info)
+	if err = fileList.Deserialization(src); err != nil {
+		return nil, fmt.Errorf("GetFileList error: %s", err.Error())
 	}
+	return &fileList, nil
 }
 
 func (c *Core) GetFileInfo(fileHashStr string) (*fs.FileInfo, error) {
+	return c.GetFileInfoContext(context.Background(), fileHashStr)
+}
+
+func (c *Core) GetFileInfoContext(ctx context.Context, fileHashStr string) (*fs.FileInfo, error) {
 	fileHash := []byte(fileHashStr)
-	ret, err := c.OntSdk.Native.PreExecInvokeNativeContract(contractAddr, contractVersion,
-		fs.FS_GET_FILE_INFO, []interface{}{fileHash},
-	)
+	info, err := c.preExec(ctx, "GetFileInfo", fs.FS_GET_FILE_INFO, []interface{}{fileHash})
 	if err != nil {
 		return nil, err
 	}
-	data, err := ret.Result.ToByteArray()
-	if err != nil {
-		return nil, fmt.Errorf("GetFileInfo result toByteArray: %s", err.Error())
-	}
 
 	var fileInfo fs.FileInfo
-	retInfo := fs.DecRet(data)
-	if retInfo.Ret {
-		src := ccom.NewZeroCopySource(retInfo.Info)
-		if err = fileInfo.Deserialization(src); err != nil {
-			return nil, fmt.Errorf("GetFileInfo error: %s", err.Error())
-		}
-		return &fileInfo, err
-	} else {
-		return nil, errors.New(string(retInfo.Info))
+	src := ccom.NewZeroCopySource(info)
+	if err = fileInfo.Deserialization(src); err != nil {
+		return nil, fmt.Errorf("GetFileInfo error: %s", err.Error())
 	}
+	return &fileInfo, nil
 }
 
+// StoreFiles stores filesInfo in as many transactions as the batch limits of
+// c require. The returned hash is that of the first transaction, and the
+// errors merge the per-file failures reported by the contract for every one.
 func (c *Core) StoreFiles(filesInfo []common.FileStore) ([]byte, error, *fs.Errors) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil"), nil
+	return c.StoreFilesContext(context.Background(), filesInfo)
+}
+
+func (c *Core) StoreFilesContext(ctx context.Context, filesInfo []common.FileStore) ([]byte, error, *fs.Errors) {
+	return legacyBatchResult(c.StoreFilesBatchContext(ctx, filesInfo))
+}
+
+// StoreFilesBatch is StoreFiles with the outcome of each file matched back to
+// its input. The Batch variants of the other file methods work the same way.
+func (c *Core) StoreFilesBatch(filesInfo []common.FileStore) (*BatchResult, error) {
+	return c.StoreFilesBatchContext(context.Background(), filesInfo)
+}
+
+func (c *Core) StoreFilesBatchContext(ctx context.Context, filesInfo []common.FileStore) (*BatchResult, error) {
+	if err := c.checkPlacement(ctx, filesInfo); err != nil {
+		return nil, err
+	}
+	return c.runBatch(ctx, storeBatch(filesInfo), func(start, end int) ([]byte, error) {
+		return c.storeFilesParam(filesInfo[start:end])
+	})
+}
+
+// StoreFilesAsync submits all of filesInfo in a single transaction.
+func (c *Core) StoreFilesAsync(ctx context.Context, filesInfo []common.FileStore) (*PendingTx, error) {
+	if err := c.checkPlacement(ctx, filesInfo); err != nil {
+		return nil, err
+	}
+	param, err := c.storeFilesParam(filesInfo)
+	if err != nil {
+		return nil, err
+	}
+	return c.invokeAsync(ctx, fs.FS_STORE_FILES, []interface{}{param}, storeBatch(filesInfo))
+}
+
+func (c *Core) storeFilesParam(filesInfo []common.FileStore) ([]byte, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_STORE_FILES}
 	}
 
 	fileInfoList := fs.FileInfoList{}
 	for _, fileInfo := range filesInfo {
 		if fileInfo.PdpInterval < defaultMinPdpInterval {
-			return nil, errors.New("pdpInterval value is too small"), nil
+			return nil, errors.New("pdpInterval value is too small")
 		}
 		fsFileInfo := fs.FileInfo{
 			FileHash:       []byte(fileInfo.FileHash),
-			FileOwner:      c.DefAcc.Address,
+			FileOwner:      c.signer().Address(),
 			FileDesc:       []byte(fileInfo.FileDesc),
 			FileBlockCount: fileInfo.FileBlockCount,
 			RealFileSize:   fileInfo.RealFileSize,
@@ -574,49 +680,45 @@ func (c *Core) StoreFiles(filesInfo []common.FileStore) ([]byte, error, *fs.Erro
 
 	sink := ccom.NewZeroCopySink(nil)
 	fileInfoList.Serialization(sink)
+	return sink.Bytes(), nil
+}
 
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion,
-		contractAddr, fs.FS_STORE_FILES, []interface{}{sink.Bytes()})
-	if err != nil {
-		return nil, err, nil
-	}
+func (c *Core) TransferFiles(fileTransfers []common.FileTransfer) ([]byte, error, *fs.Errors) {
+	return c.TransferFilesContext(context.Background(), fileTransfers)
+}
 
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("StoreFiles tx is not confirmed"), nil
-	}
+func (c *Core) TransferFilesContext(ctx context.Context, fileTransfers []common.FileTransfer) ([]byte, error, *fs.Errors) {
+	return legacyBatchResult(c.TransferFilesBatchContext(ctx, fileTransfers))
+}
 
-	event, err := c.OntSdk.GetSmartContractEvent(txHash.ToHexString())
-	if err != nil {
-		return txHash.ToArray(), err, nil
-	}
+func (c *Core) TransferFilesBatch(fileTransfers []common.FileTransfer) (*BatchResult, error) {
+	return c.TransferFilesBatchContext(context.Background(), fileTransfers)
+}
 
-	var errorData string
-	for _, notify := range event.Notify  {
-		if 0 == strings.Compare(contractAddrStr, notify.ContractAddress) {
-			errorData = notify.States.(string)
-		}
-	}
+func (c *Core) TransferFilesBatchContext(ctx context.Context, fileTransfers []common.FileTransfer) (*BatchResult, error) {
+	return c.runBatch(ctx, transferBatch(fileTransfers), func(start, end int) ([]byte, error) {
+		return c.transferFilesParam(fileTransfers[start:end])
+	})
+}
 
-	var objErrors fs.Errors
-	if len(errorData) == 0 {
-		err := fmt.Errorf("GetSmartContractEvent error")
-		return txHash.ToArray(), err, nil
+func (c *Core) TransferFilesAsync(ctx context.Context, fileTransfers []common.FileTransfer) (*PendingTx, error) {
+	param, err := c.transferFilesParam(fileTransfers)
+	if err != nil {
+		return nil, err
 	}
-	err = objErrors.FromString(errorData)
-	return txHash.ToArray(), err, &objErrors
+	return c.invokeAsync(ctx, fs.FS_TRANSFER_FILES, []interface{}{param}, transferBatch(fileTransfers))
 }
 
-func (c *Core) TransferFiles(fileTransfers []common.FileTransfer) ([]byte, error, *fs.Errors) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil"), nil
+func (c *Core) transferFilesParam(fileTransfers []common.FileTransfer) ([]byte, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_TRANSFER_FILES}
 	}
 
 	fileTransferList := fs.FileTransferList{}
 	for _, fileRenew := range fileTransfers {
 		fsFileTransfer := fs.FileTransfer{
 			FileHash: []byte(fileRenew.FileHash),
-			OriOwner: c.DefAcc.Address,
+			OriOwner: c.signer().Address(),
 			NewOwner: fileRenew.NewOwner,
 		}
 		fileTransferList.FilesTransfer = append(fileTransferList.FilesTransfer, fsFileTransfer)
@@ -624,50 +726,46 @@ func (c *Core) TransferFiles(fileTransfers []common.FileTransfer) ([]byte, error
 
 	sink := ccom.NewZeroCopySink(nil)
 	fileTransferList.Serialization(sink)
+	return sink.Bytes(), nil
+}
 
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_TRANSFER_FILES, []interface{}{sink.Bytes()})
-	if err != nil {
-		return nil, err, nil
-	}
+func (c *Core) RenewFiles(filesRenew []common.FileRenew) ([]byte, error, *fs.Errors) {
+	return c.RenewFilesContext(context.Background(), filesRenew)
+}
 
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("TransferFiles tx is not confirmed"), nil
-	}
+func (c *Core) RenewFilesContext(ctx context.Context, filesRenew []common.FileRenew) ([]byte, error, *fs.Errors) {
+	return legacyBatchResult(c.RenewFilesBatchContext(ctx, filesRenew))
+}
 
-	event, err := c.OntSdk.GetSmartContractEvent(txHash.ToHexString())
-	if err != nil {
-		return txHash.ToArray(), err, nil
-	}
+func (c *Core) RenewFilesBatch(filesRenew []common.FileRenew) (*BatchResult, error) {
+	return c.RenewFilesBatchContext(context.Background(), filesRenew)
+}
 
-	var errorData string
-	for _, notify := range event.Notify  {
-		if 0 == strings.Compare(contractAddrStr, notify.ContractAddress) {
-			errorData = notify.States.(string)
-		}
-	}
+func (c *Core) RenewFilesBatchContext(ctx context.Context, filesRenew []common.FileRenew) (*BatchResult, error) {
+	return c.runBatch(ctx, renewBatch(filesRenew), func(start, end int) ([]byte, error) {
+		return c.renewFilesParam(filesRenew[start:end])
+	})
+}
 
-	var objErrors fs.Errors
-	if len(errorData) == 0 {
-		err := fmt.Errorf("GetSmartContractEvent error")
-		return txHash.ToArray(), err, nil
+func (c *Core) RenewFilesAsync(ctx context.Context, filesRenew []common.FileRenew) (*PendingTx, error) {
+	param, err := c.renewFilesParam(filesRenew)
+	if err != nil {
+		return nil, err
 	}
-	err = objErrors.FromString(errorData)
-	return txHash.ToArray(), err, &objErrors
+	return c.invokeAsync(ctx, fs.FS_RENEW_FILES, []interface{}{param}, renewBatch(filesRenew))
 }
 
-func (c *Core) RenewFiles(filesRenew []common.FileRenew) ([]byte, error, *fs.Errors) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil"), nil
+func (c *Core) renewFilesParam(filesRenew []common.FileRenew) ([]byte, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_RENEW_FILES}
 	}
 
 	fileReNewList := fs.FileReNewList{}
 	for _, fileRenew := range filesRenew {
 		fsFileRenew := fs.FileReNew{
 			FileHash:       []byte(fileRenew.FileHash),
-			FileOwner:      c.WalletAddr,
-			Payer:          c.WalletAddr,
+			FileOwner:      c.signer().Address(),
+			Payer:          c.payerAddress(),
 			NewTimeExpired: fileRenew.RenewTime,
 		}
 		fileReNewList.FilesReNew = append(fileReNewList.FilesReNew, fsFileRenew)
@@ -675,42 +773,38 @@ func (c *Core) RenewFiles(filesRenew []common.FileRenew) ([]byte, error, *fs.Err
 
 	sink := ccom.NewZeroCopySink(nil)
 	fileReNewList.Serialization(sink)
+	return sink.Bytes(), nil
+}
 
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc,
-		contractVersion, contractAddr, fs.FS_RENEW_FILES, []interface{}{sink.Bytes()})
+func (c *Core) DeleteFiles(fileHashes []string) ([]byte, error, *fs.Errors) {
+	return c.DeleteFilesContext(context.Background(), fileHashes)
+}
 
-	if err != nil {
-		return nil, err, nil
-	}
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("RenewFiles tx is not confirmed"), nil
-	}
+func (c *Core) DeleteFilesContext(ctx context.Context, fileHashes []string) ([]byte, error, *fs.Errors) {
+	return legacyBatchResult(c.DeleteFilesBatchContext(ctx, fileHashes))
+}
 
-	event, err := c.OntSdk.GetSmartContractEvent(txHash.ToHexString())
-	if err != nil {
-		return txHash.ToArray(), err, nil
-	}
+func (c *Core) DeleteFilesBatch(fileHashes []string) (*BatchResult, error) {
+	return c.DeleteFilesBatchContext(context.Background(), fileHashes)
+}
 
-	var errorData string
-	for _, notify := range event.Notify  {
-		if 0 == strings.Compare(contractAddrStr, notify.ContractAddress) {
-			errorData = notify.States.(string)
-		}
-	}
+func (c *Core) DeleteFilesBatchContext(ctx context.Context, fileHashes []string) (*BatchResult, error) {
+	return c.runBatch(ctx, deleteBatch(fileHashes), func(start, end int) ([]byte, error) {
+		return c.deleteFilesParam(fileHashes[start:end])
+	})
+}
 
-	var objErrors fs.Errors
-	if len(errorData) == 0 {
-		err := fmt.Errorf("GetSmartContractEvent error")
-		return txHash.ToArray(), err, nil
+func (c *Core) DeleteFilesAsync(ctx context.Context, fileHashes []string) (*PendingTx, error) {
+	param, err := c.deleteFilesParam(fileHashes)
+	if err != nil {
+		return nil, err
 	}
-	err = objErrors.FromString(errorData)
-	return txHash.ToArray(), err, &objErrors
+	return c.invokeAsync(ctx, fs.FS_DELETE_FILES, []interface{}{param}, deleteBatch(fileHashes))
 }
 
-func (c *Core) DeleteFiles(fileHashes []string) ([]byte, error, *fs.Errors) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil"), nil
+func (c *Core) deleteFilesParam(fileHashes []string) ([]byte, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_DELETE_FILES}
 	}
 
 	var fileDelList fs.FileDelList
@@ -720,47 +814,30 @@ func (c *Core) DeleteFiles(fileHashes []string) ([]byte, error, *fs.Errors) {
 
 	sink := ccom.NewZeroCopySink(nil)
 	fileDelList.Serialization(sink)
+	return sink.Bytes(), nil
+}
 
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_DELETE_FILES, []interface{}{sink.Bytes()})
-	if err != nil {
-		return nil, err, nil
-	}
-
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("DeleteFiles tx is not confirmed"), nil
-	}
+func (c *Core) FileReadPledge(fileHashStr string, readPlans []fs.ReadPlan) ([]byte, error) {
+	return c.FileReadPledgeContext(context.Background(), fileHashStr, readPlans)
+}
 
-	event, err := c.OntSdk.GetSmartContractEvent(txHash.ToHexString())
+func (c *Core) FileReadPledgeContext(ctx context.Context, fileHashStr string, readPlans []fs.ReadPlan) ([]byte, error) {
+	pending, err := c.FileReadPledgeAsync(ctx, fileHashStr, readPlans)
 	if err != nil {
-		return txHash.ToArray(), err, nil
-	}
-
-	var errorData string
-	for _, notify := range event.Notify  {
-		if 0 == strings.Compare(contractAddrStr, notify.ContractAddress) {
-			errorData = notify.States.(string)
-		}
-	}
-
-	var objErrors fs.Errors
-	if len(errorData) == 0 {
-		err := fmt.Errorf("GetSmartContractEvent error")
-		return txHash.ToArray(), err, nil
+		return nil, err
 	}
-	err = objErrors.FromString(errorData)
-	return txHash.ToArray(), err, &objErrors
+	return pending.result(ctx)
 }
 
-func (c *Core) FileReadPledge(fileHashStr string, readPlans []fs.ReadPlan) ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil")
+func (c *Core) FileReadPledgeAsync(ctx context.Context, fileHashStr string,
+	readPlans []fs.ReadPlan) (*PendingTx, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_READ_FILE_PLEDGE}
 	}
 
 	fileReadPledge := &fs.ReadPledge{
 		FileHash:     []byte(fileHashStr),
-		Downloader:   c.DefAcc.Address,
+		Downloader:   c.signer().Address(),
 		BlockHeight:  0,
 		ExpireHeight: 0,
 		RestMoney:    0,
@@ -770,53 +847,49 @@ func (c *Core) FileReadPledge(fileHashStr string, readPlans []fs.ReadPlan) ([]by
 	sink := ccom.NewZeroCopySink(nil)
 	fileReadPledge.Serialization(sink)
 
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_READ_FILE_PLEDGE, []interface{}{sink.Bytes()})
+	return c.invokeAsync(ctx, fs.FS_READ_FILE_PLEDGE, []interface{}{sink.Bytes()}, nil)
+}
+
+func (c *Core) CancelFileRead(fileHashStr string) ([]byte, error) {
+	return c.CancelFileReadContext(context.Background(), fileHashStr)
+}
+
+func (c *Core) CancelFileReadContext(ctx context.Context, fileHashStr string) ([]byte, error) {
+	pending, err := c.CancelFileReadAsync(ctx, fileHashStr)
 	if err != nil {
 		return nil, err
 	}
-
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("FileReadPledge tx is not confirmed")
-	}
-	return txHash.ToArray(), nil
+	return pending.result(ctx)
 }
 
-func (c *Core) CancelFileRead(fileHashStr string) ([]byte, error) {
-	if c.DefAcc == nil {
-		return nil, errors.New("DefAcc is nil")
+func (c *Core) CancelFileReadAsync(ctx context.Context, fileHashStr string) (*PendingTx, error) {
+	if c.signer() == nil {
+		return nil, &NoSignerError{Method: fs.FS_CANCEL_FILE_READ}
 	}
 	fileHash := []byte(fileHashStr)
 	getReadPledge := &fs.GetReadPledge{
 		FileHash:   fileHash,
-		Downloader: c.DefAcc.Address,
-	}
-	txHash, err := c.OntSdk.Native.InvokeNativeContract(c.GasPrice, c.GasLimit, c.DefAcc, contractVersion, contractAddr,
-		fs.FS_CANCEL_FILE_READ, []interface{}{getReadPledge})
-	if err != nil {
-		return nil, err
+		Downloader: c.signer().Address(),
 	}
-
-	confirmed, err := c.PollForTxConfirmed(time.Duration(common.TX_CONFIRM_TIMEOUT)*time.Second, txHash.ToArray())
-	if err != nil || !confirmed {
-		return txHash.ToArray(), errors.New("CancelFileRead tx is not confirmed")
-	}
-	return txHash.ToArray(), nil
+	return c.invokeAsync(ctx, fs.FS_CANCEL_FILE_READ, []interface{}{getReadPledge}, nil)
 }
 
 func (c *Core) GenPassport(height uint32, blockHash []byte) ([]byte, error) {
+	signer := c.signer()
+	if signer == nil {
+		return nil, &NoSignerError{Method: "GenPassport"}
+	}
 	passPort := fs.Passport{
 		BlockHeight: uint64(height),
 		BlockHash:   blockHash,
-		WalletAddr:  c.DefAcc.Address,
-		PublicKey:   keypair.SerializePublicKey(c.DefAcc.PublicKey),
+		WalletAddr:  signer.Address(),
+		PublicKey:   keypair.SerializePublicKey(signer.PublicKey()),
 	}
 
 	sinkTmp := ccom.NewZeroCopySink(nil)
 	passPort.Serialization(sinkTmp)
 
-	signData, err := common.Sign(c.DefAcc, sinkTmp.Bytes())
+	signData, err := signer.Sign(sinkTmp.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("GenPassport Sign error: %s", err.Error())
 	}
@@ -830,9 +903,13 @@ func (c *Core) GenPassport(height uint32, blockHash []byte) ([]byte, error) {
 
 func (c *Core) GenFileReadSettleSlice(fileHash []byte, payTo ccom.Address, sliceId uint64,
 	pledgeHeight uint64) (*fs.FileReadSettleSlice, error) {
+	signer := c.signer()
+	if signer == nil {
+		return nil, &NoSignerError{Method: "GenFileReadSettleSlice"}
+	}
 	settleSlice := fs.FileReadSettleSlice{
 		FileHash:     fileHash,
-		PayFrom:      c.DefAcc.Address,
+		PayFrom:      signer.Address(),
 		PayTo:        payTo,
 		SliceId:      sliceId,
 		PledgeHeight: pledgeHeight,
@@ -840,31 +917,63 @@ func (c *Core) GenFileReadSettleSlice(fileHash []byte, payTo ccom.Address, slice
 	sink := ccom.NewZeroCopySink(nil)
 	settleSlice.Serialization(sink)
 
-	signData, err := common.Sign(c.DefAcc, sink.Bytes())
+	signData, err := signer.Sign(sink.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("FileReadSettleSlice Sign error: %s", err.Error())
 	}
 	settleSlice.Sig = signData
-	settleSlice.PubKey = keypair.SerializePublicKey(c.DefAcc.PublicKey)
+	settleSlice.PubKey = keypair.SerializePublicKey(signer.PublicKey())
 	return &settleSlice, nil
 }
 
 func (c *Core) PollForTxConfirmed(timeout time.Duration, txHash []byte) (bool, error) {
+	return c.PollForTxConfirmedContext(context.Background(), timeout, txHash)
+}
+
+// PollForTxConfirmedContext waits until txHash is in a block, timeout has
+// passed or ctx is done. When ctx is done it returns ctx.Err(). Without a
+// Tracker the node is polled once per second.
+func (c *Core) PollForTxConfirmedContext(ctx context.Context, timeout time.Duration, txHash []byte) (bool, error) {
+	if _, err := c.waitConfirmed(ctx, timeout, txHash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// waitConfirmed returns the height of the block holding txHash.
+func (c *Core) waitConfirmed(ctx context.Context, timeout time.Duration, txHash []byte) (uint32, error) {
 	if len(txHash) == 0 {
-		return false, fmt.Errorf("txHash is empty")
+		return 0, fmt.Errorf("txHash is empty")
 	}
 	txHashStr := hex.EncodeToString(ccom.ToArrayReverse(txHash))
+	if c.Tracker != nil {
+		return c.Tracker.Wait(ctx, txHashStr, timeout)
+	}
 	secs := int(timeout / time.Second)
 	if secs <= 0 {
 		secs = 1
 	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 	for i := 0; i < secs; i++ {
-		time.Sleep(time.Second)
-		ret, err := c.OntSdk.GetBlockHeightByTxHash(txHashStr)
-		if err != nil || ret == 0 {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-ticker.C:
+		}
+		var height uint32
+		err := callContext(ctx, func() error {
+			var err error
+			height, err = c.Backend.GetBlockHeightByTxHash(txHashStr)
+			return err
+		})
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, ctxErr
+		}
+		if err != nil || height == 0 {
 			continue
 		}
-		return true, nil
+		return height, nil
 	}
-	return false, fmt.Errorf("timeout after %d (s)", secs)
+	return 0, fmt.Errorf("timeout after %d (s)", secs)
 }
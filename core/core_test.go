@@ -0,0 +1,604 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontfs-contract-api/sim"
+	ont "github.com/ontio/ontology-go-sdk"
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	ccom "github.com/ontio/ontology/common"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// simConfig is the configuration of the simulated chains of the tests, which
+// start at the genesis time the file and node lifetimes are counted from.
+func simConfig() sim.Config {
+	cfg := sim.DefaultConfig()
+	cfg.GenesisTime = 1577836800
+	return cfg
+}
+
+// newTestCore returns a Core signing with a new account on backend.
+func newTestCore(t *testing.T, backend core.ChainBackend) *core.Core {
+	c := core.InitWithBackend(backend, ont.NewAccount(), 0, 20000)
+	if c == nil {
+		t.Fatalf("InitWithBackend error")
+	}
+	return c
+}
+
+func newSimCore(t *testing.T, manualMining bool) (*sim.Simulator, *core.Core) {
+	cfg := simConfig()
+	cfg.ManualMining = manualMining
+	chain := sim.NewSimulator(cfg)
+	return chain, newTestCore(t, chain)
+}
+
+// storeFilesParams returns the params of a StoreFiles transaction of owner.
+func storeFilesParams(owner ccom.Address, fileStores []common.FileStore) []interface{} {
+	var fileInfoList fs.FileInfoList
+	for _, fileStore := range fileStores {
+		fileInfoList.FilesI = append(fileInfoList.FilesI, fs.FileInfo{
+			FileHash:       []byte(fileStore.FileHash),
+			FileOwner:      owner,
+			FileBlockCount: fileStore.FileBlockCount,
+			CopyNumber:     fileStore.CopyNumber,
+			PdpInterval:    fileStore.PdpInterval,
+			TimeExpired:    fileStore.TimeExpired,
+			StorageType:    fileStore.StorageType,
+		})
+	}
+	sink := ccom.NewZeroCopySink(nil)
+	fileInfoList.Serialization(sink)
+	return []interface{}{sink.Bytes()}
+}
+
+func TestCore_ContextCancelsConfirmation(t *testing.T) {
+	_, c := newSimCore(t, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	txHash, err := c.NodeRegisterContext(ctx, 1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("NodeRegisterContext error: %v", err)
+	}
+	if len(txHash) == 0 {
+		t.Fatalf("NodeRegisterContext did not return the submitted tx hash")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("NodeRegisterContext returned after %s", elapsed)
+	}
+}
+
+func TestCore_ContextCancelledBeforeCall(t *testing.T) {
+	_, c := newSimCore(t, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GetGlobalParamContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetGlobalParamContext error: %v", err)
+	}
+	if _, err, _ := c.DeleteFilesContext(ctx, []string{"file"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("DeleteFilesContext error: %v", err)
+	}
+}
+
+func TestCore_AsyncSubmission(t *testing.T) {
+	chain, c := newSimCore(t, true)
+
+	pending, err := c.NodeRegisterAsync(context.Background(), 1024*1024, 1577836800+100000, 600,
+		"tcp://127.0.0.1:3389")
+	if err != nil {
+		t.Fatalf("NodeRegisterAsync error: %s", err.Error())
+	}
+	if len(pending.Hash()) == 0 {
+		t.Fatalf("PendingTx has no hash")
+	}
+	select {
+	case <-pending.Done():
+		t.Fatalf("PendingTx is done before the tx is mined")
+	default:
+	}
+	if pending.Receipt() != nil {
+		t.Fatalf("PendingTx has a receipt before the tx is mined")
+	}
+
+	chain.Mine()
+	if err = core.WaitAll(context.Background(), pending); err != nil {
+		t.Fatalf("WaitAll error: %s", err.Error())
+	}
+	receipt := pending.Receipt()
+	if receipt == nil || receipt.Height != chain.Height() {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+}
+
+// eventlessBackend confirms transactions without finding their events.
+type eventlessBackend struct {
+	*sim.Simulator
+}
+
+func (b *eventlessBackend) GetSmartContractEvent(txHash string) (*sdkcom.SmartContactEvent, error) {
+	return nil, nil
+}
+
+func TestCore_AsyncBatchWithoutEvent(t *testing.T) {
+	chain := sim.NewSimulator(simConfig())
+	c := newTestCore(t, &eventlessBackend{Simulator: chain})
+
+	pending, err := c.StoreFilesAsync(context.Background(), []common.FileStore{{
+		FileHash: "EventlessFile", FileBlockCount: 4, CopyNumber: 1, PdpInterval: 600,
+		TimeExpired: 1577836800 + 3*3600, StorageType: fs.FileStorageTypeUseFile,
+	}})
+	if err != nil {
+		t.Fatalf("StoreFilesAsync error: %s", err.Error())
+	}
+	// the transaction is confirmed, only its per-file outcome is unknown
+	receipt, err := pending.Wait(context.Background())
+	var rpcErr *core.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("Wait error: %v", err)
+	}
+	if receipt == nil || receipt.Height != chain.Height() || receipt.Batch != nil || pending.Receipt() != receipt {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+}
+
+func TestCore_ErrorTypes(t *testing.T) {
+	chain, c := newSimCore(t, false)
+	c.GasEstimation = true
+
+	_, err := c.GetNodeInfo(c.WalletAddr)
+	var rejected *core.ContractRejectedError
+	if !errors.As(err, &rejected) || rejected.Method != fs.FS_NODE_QUERY {
+		t.Fatalf("GetNodeInfo error: %v", err)
+	}
+
+	_, err = c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389")
+	if err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	// the estimation rejects the invocation with the error of the contract
+	_, err = c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389")
+	if !errors.As(err, &rejected) || len(rejected.TxHash) != 0 ||
+		!strings.Contains(rejected.Message, "node has registered") {
+		t.Fatalf("second NodeRegister error: %v", err)
+	}
+
+	// without estimation the rejection comes from the executed transaction
+	fixed := core.InitWithBackend(chain, c.DefAcc, 0, 20000)
+	_, err = fixed.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389")
+	if !errors.As(err, &rejected) || len(rejected.TxHash) == 0 {
+		t.Fatalf("third NodeRegister error: %v", err)
+	}
+
+	query := core.InitWithBackend(chain, nil, 0, 20000)
+	if _, err = query.NodeCancel(); !errors.Is(err, core.ErrNoSigner) {
+		t.Fatalf("NodeCancel error: %v", err)
+	}
+}
+
+func TestCore_StoreFilesBatch(t *testing.T) {
+	_, c := newSimCore(t, false)
+
+	result, err := c.StoreFilesBatch([]common.FileStore{
+		{
+			FileHash:       "BatchFile",
+			FileBlockCount: 4,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			TimeExpired:    1577836800 + 3*3600,
+			StorageType:    fs.FileStorageTypeUseFile,
+		},
+		{
+			FileHash:       "BatchFileTooShort",
+			FileBlockCount: 4,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			TimeExpired:    1577836800 + 60,
+			StorageType:    fs.FileStorageTypeUseFile,
+		},
+	})
+	if err != nil {
+		t.Fatalf("StoreFilesBatch error: %s", err.Error())
+	}
+	if len(result.Items) != 2 || len(result.TxHash) == 0 || result.AllSucceeded() {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	failed := result.Failed()
+	if len(failed) != 1 || failed[0].FileHash != "BatchFileTooShort" || failed[0].Code != core.BatchErrInvalidParam {
+		t.Fatalf("unexpected failed items: %+v", failed)
+	}
+	if input, ok := result.Succeeded()[0].Input.(common.FileStore); !ok || input.FileHash != "BatchFile" {
+		t.Fatalf("unexpected succeeded input: %+v", result.Succeeded()[0].Input)
+	}
+}
+
+// failingBackend fails the submission of the failAt-th transaction.
+type failingBackend struct {
+	*sim.Simulator
+	lock    sync.Mutex
+	invokes int
+	failAt  int
+}
+
+func (b *failingBackend) InvokeNativeContract(gasPrice, gasLimit uint64, signer core.Signer, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (ccom.Uint256, error) {
+	b.lock.Lock()
+	b.invokes++
+	fail := b.invokes == b.failAt
+	b.lock.Unlock()
+	if fail {
+		return ccom.UINT256_EMPTY, errors.New("connection refused")
+	}
+	return b.Simulator.InvokeNativeContract(gasPrice, gasLimit, signer, version, contractAddress, method, params)
+}
+
+func TestCore_BatchChunking(t *testing.T) {
+	backend := &failingBackend{Simulator: sim.NewSimulator(simConfig()), failAt: 2}
+	c := newTestCore(t, backend)
+	c.BatchGasPerFile = 10000
+	c.BatchParallelism = 1
+
+	var fileStores []common.FileStore
+	for i := 0; i < 5; i++ {
+		fileStores = append(fileStores, common.FileStore{
+			FileHash:       fmt.Sprintf("ChunkFile%d", i),
+			FileBlockCount: 4,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			TimeExpired:    1577836800 + 3*3600,
+			StorageType:    fs.FileStorageTypeUseFile,
+		})
+	}
+	result, err := c.StoreFilesBatch(fileStores)
+	var batchErr *core.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Failed != 1 || batchErr.Chunks != 3 {
+		t.Fatalf("StoreFilesBatch error: %v", err)
+	}
+	var rpcErr *core.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("StoreFilesBatch error does not wrap the rpc error: %v", err)
+	}
+	failedChunks := result.FailedChunks()
+	if len(failedChunks) != 1 || failedChunks[0].Start != 2 || failedChunks[0].End != 4 {
+		t.Fatalf("unexpected failed chunks: %+v", failedChunks)
+	}
+	if len(result.Succeeded()) != 3 || result.Items[4].Code != core.BatchErrNone {
+		t.Fatalf("unexpected items: %+v", result.Items)
+	}
+	if result.Items[2].Code != core.BatchErrTxFailed || result.Items[3].Code != core.BatchErrTxFailed {
+		t.Fatalf("unexpected failed items: %+v", result.Failed())
+	}
+	if bytes.Equal(result.Items[0].TxHash, result.Items[4].TxHash) {
+		t.Fatalf("chunks share a tx hash")
+	}
+
+	c.BatchParallelism = 3
+	for i := range fileStores {
+		fileStores[i].FileHash += "Parallel"
+	}
+	result, err = c.StoreFilesBatch(fileStores)
+	if err != nil || len(result.Chunks) != 3 || !result.AllSucceeded() {
+		t.Fatalf("parallel StoreFilesBatch result %+v, error %v", result, err)
+	}
+
+	if _, err = c.StoreFilesBatch(nil); err == nil {
+		t.Fatalf("StoreFilesBatch of no file succeeded")
+	}
+}
+
+func TestCore_BatchChunkingEstimated(t *testing.T) {
+	cfg := simConfig()
+	cfg.GasPerByte = 10
+	chain := sim.NewSimulator(cfg)
+	c := newTestCore(t, chain)
+	c.GasEstimation = true
+	c.BatchGasPerFile = 1
+
+	var fileStores []common.FileStore
+	for i := 0; i < 5; i++ {
+		fileStores = append(fileStores, common.FileStore{
+			FileHash:       fmt.Sprintf("EstimatedChunkFile%d", i),
+			FileBlockCount: 4,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			TimeExpired:    1577836800 + 3*3600,
+			StorageType:    fs.FileStorageTypeUseFile,
+		})
+	}
+	// the heuristic puts every file in one transaction, the gas of two is
+	// the most one may take
+	estimate, err := c.EstimateGas(context.Background(), fs.FS_STORE_FILES,
+		storeFilesParams(c.WalletAddr, fileStores[:2]))
+	if err != nil {
+		t.Fatalf("EstimateGas error: %s", err.Error())
+	}
+	c.GasLimit = estimate.Gas
+
+	result, err := c.StoreFilesBatch(fileStores)
+	if err != nil || !result.AllSucceeded() || len(result.Chunks) < 3 {
+		t.Fatalf("StoreFilesBatch result %+v, error %v", result, err)
+	}
+	for _, chunk := range result.Chunks {
+		if chunk.End-chunk.Start > 2 {
+			t.Fatalf("chunk of %d files exceeds the gas limit", chunk.End-chunk.Start)
+		}
+	}
+}
+
+func TestCore_EstimateGas(t *testing.T) {
+	cfg := simConfig()
+	cfg.GasPerByte = 10
+	chain := sim.NewSimulator(cfg)
+	c := newTestCore(t, chain)
+
+	fileStores := func(count int) []common.FileStore {
+		var stores []common.FileStore
+		for i := 0; i < count; i++ {
+			stores = append(stores, common.FileStore{
+				FileHash:       fmt.Sprintf("GasFile%d", i),
+				FileBlockCount: 4,
+				CopyNumber:     1,
+				PdpInterval:    600,
+				TimeExpired:    1577836800 + 3*3600,
+				StorageType:    fs.FileStorageTypeUseFile,
+			})
+		}
+		return stores
+	}
+	estimate := func(count int) *core.GasEstimate {
+		estimate, err := c.EstimateGas(context.Background(), fs.FS_STORE_FILES,
+			storeFilesParams(c.WalletAddr, fileStores(count)))
+		if err != nil {
+			t.Fatalf("EstimateGas error: %s", err.Error())
+		}
+		return estimate
+	}
+
+	small, large := estimate(1), estimate(50)
+	if large.Gas <= small.Gas || large.GasLimit != large.Gas+large.Gas*20/100 {
+		t.Fatalf("unexpected estimates: %+v, %+v", small, large)
+	}
+	if chain.Height() != 0 {
+		t.Fatalf("EstimateGas submitted a transaction")
+	}
+
+	// the fixed limit is too low for the large batch, the estimate is not
+	estimating := core.InitWithBackend(chain, c.DefAcc, 0, 20000)
+	estimating.GasEstimation = true
+	pending, err := estimating.StoreFilesAsync(context.Background(), fileStores(50))
+	if err != nil {
+		t.Fatalf("StoreFilesAsync error: %s", err.Error())
+	}
+	if _, err = pending.Wait(context.Background()); err != nil {
+		t.Fatalf("StoreFilesAsync tx error: %s", err.Error())
+	}
+	var rpcErr *core.RPCError
+	if _, err = c.StoreFilesAsync(context.Background(), fileStores(50)); !errors.As(err, &rpcErr) {
+		t.Fatalf("StoreFilesAsync with a fixed limit error: %v", err)
+	}
+}
+
+func TestCore_DryRun(t *testing.T) {
+	chain, c := newSimCore(t, false)
+
+	result, err := c.DryRun(context.Background(), fs.FS_NODE_CANCEL, []interface{}{c.WalletAddr})
+	if err != nil || result.Method != fs.FS_NODE_CANCEL || result.Succeeded {
+		t.Fatalf("unexpected NodeCancel result: %+v, error %v", result, err)
+	}
+	result, err = c.DryRun(context.Background(), fs.FS_STORE_FILES, storeFilesParams(c.WalletAddr, []common.FileStore{
+		{
+			FileHash:       "DryRunFile",
+			FileBlockCount: 4,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			TimeExpired:    1577836800 + 3*3600,
+			StorageType:    fs.FileStorageTypeUseFile,
+		},
+		{
+			FileHash:    "DryRunFileEmpty",
+			CopyNumber:  1,
+			PdpInterval: 600,
+			TimeExpired: 1577836800 + 3*3600,
+			StorageType: fs.FileStorageTypeUseFile,
+		},
+	}))
+	if err != nil || !result.Succeeded || result.Ret == nil || !result.Ret.Ret || result.Gas == 0 {
+		t.Fatalf("unexpected StoreFiles result: %+v, error %v", result, err)
+	}
+	if len(result.Errors.ObjectErrors) != 1 ||
+		core.ClassifyBatchError(result.Errors.ObjectErrors["DryRunFileEmpty"]) != core.BatchErrInvalidParam {
+		t.Fatalf("unexpected file errors: %+v", result.Errors)
+	}
+	if chain.Height() != 0 {
+		t.Fatalf("DryRun submitted a transaction")
+	}
+}
+
+func TestCore_WithAccount(t *testing.T) {
+	_, c := newSimCore(t, false)
+	customer := c.WithAccount(ont.NewAccount())
+	if customer.WalletAddr == c.WalletAddr || c.DefAcc == customer.DefAcc {
+		t.Fatalf("view is bound to the account of c")
+	}
+
+	if _, err := customer.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	if _, err := c.GetNodeInfo(customer.WalletAddr); err != nil {
+		t.Fatalf("GetNodeInfo of the view account error: %s", err.Error())
+	}
+	if _, err := c.GetNodeInfo(c.WalletAddr); err == nil {
+		t.Fatalf("node is registered for the account of c")
+	}
+	if _, err := c.WithAccountLabel("customer"); err == nil {
+		t.Fatalf("WithAccountLabel succeeded without a wallet")
+	}
+}
+
+func TestCore_Signer(t *testing.T) {
+	_, c := newSimCore(t, false)
+	// a signer set in place of the wallet account, as a remote signer is
+	signer := core.NewLocalSigner(ont.NewAccount())
+	c.Signer = signer
+	c.GasEstimation = true
+
+	if _, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	if _, err := c.GetNodeInfo(signer.Address()); err != nil {
+		t.Fatalf("GetNodeInfo of the signer error: %s", err.Error())
+	}
+	if _, err := c.GetNodeInfo(c.WalletAddr); err == nil {
+		t.Fatalf("node is registered for the wallet account")
+	}
+	if _, err := c.NodeUpdate(2*1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3390"); err != nil {
+		t.Fatalf("NodeUpdate error: %s", err.Error())
+	}
+	// the node has no profit yet, but the signer is its owner
+	var rejected *core.ContractRejectedError
+	if _, err := c.NodeWithDrawProfit(); !errors.As(err, &rejected) ||
+		!strings.Contains(rejected.Message, "profit is zero") {
+		t.Fatalf("NodeWithDrawProfit error: %v", err)
+	}
+	if _, err := c.NodeCancel(); err != nil {
+		t.Fatalf("NodeCancel error: %s", err.Error())
+	}
+
+	if _, err := c.CreateSpace(1024, 1, 600, 1577836800+3*3600); err != nil {
+		t.Fatalf("CreateSpace error: %s", err.Error())
+	}
+	if space, err := c.GetSpaceInfo(); err != nil || space.SpaceOwner != signer.Address() {
+		t.Fatalf("GetSpaceInfo of the signer: %v %+v", err, space)
+	}
+}
+
+func TestCore_ThirdPartyPayer(t *testing.T) {
+	_, c := newSimCore(t, false)
+	customer := c.WithAccount(ont.NewAccount())
+	billing := core.NewLocalSigner(ont.NewAccount())
+
+	_, err, objErrors := customer.StoreFiles([]common.FileStore{
+		{FileHash: "PaidFile", FileBlockCount: 4, CopyNumber: 1, PdpInterval: 600,
+			TimeExpired: 1577836800 + 3*3600, StorageType: fs.FileStorageTypeUseFile},
+	})
+	if err != nil || objErrors != nil {
+		t.Fatalf("StoreFiles error: %v %v", err, objErrors)
+	}
+
+	// the customer cannot pay for the billing account
+	result, err := customer.WithPayerAddress(billing.Address()).RenewFilesBatch([]common.FileRenew{
+		{FileHash: "PaidFile", RenewTime: 1577836800 + 4*3600},
+	})
+	var payerErr *core.PayerSignatureError
+	if !errors.As(err, &payerErr) || payerErr.Payer != billing.Address() || result.AllSucceeded() {
+		t.Fatalf("expected PayerSignatureError, got %v", err)
+	}
+
+	if _, err, objErrors = customer.WithPayer(billing).RenewFiles([]common.FileRenew{
+		{FileHash: "PaidFile", RenewTime: 1577836800 + 4*3600},
+	}); err != nil || objErrors != nil {
+		t.Fatalf("RenewFiles with payer error: %v %v", err, objErrors)
+	}
+
+	renewList := fs.FileReNewList{FilesReNew: []fs.FileReNew{{
+		FileHash:       []byte("PaidFile"),
+		FileOwner:      customer.WalletAddr,
+		Payer:          billing.Address(),
+		NewTimeExpired: 1577836800 + 5*3600,
+	}}}
+	sink := ccom.NewZeroCopySink(nil)
+	renewList.Serialization(sink)
+	tx, err := customer.WithPayerAddress(billing.Address()).Export(context.Background(), fs.FS_RENEW_FILES,
+		[]interface{}{sink.Bytes()})
+	if err != nil || tx.Payer != billing.Address() || tx.Signer != customer.WalletAddr {
+		t.Fatalf("Export error: %v %+v", err, tx)
+	}
+	if _, err = c.SubmitTransaction(tx); err == nil {
+		t.Fatalf("SubmitTransaction succeeded without the payer signature")
+	}
+	if tx.Raw, err = core.CoSignTransaction(tx.Raw, billing); err != nil {
+		t.Fatalf("CoSignTransaction error: %s", err.Error())
+	}
+	pending, err := c.SubmitTransactionAsync(context.Background(), tx)
+	if err != nil {
+		t.Fatalf("SubmitTransactionAsync error: %s", err.Error())
+	}
+	receipt, err := pending.Wait(context.Background())
+	if err != nil || !bytes.Equal(receipt.TxHash, tx.TxHash) || receipt.Errors == nil ||
+		len(receipt.Errors.ObjectErrors) != 0 {
+		t.Fatalf("co-signed renewal error: %v %+v", err, receipt)
+	}
+	fileInfo, err := c.GetFileInfo("PaidFile")
+	if err != nil || fileInfo.TimeExpired != 1577836800+5*3600 {
+		t.Fatalf("unexpected file info: %v %+v", err, fileInfo)
+	}
+}
+
+func TestNew(t *testing.T) {
+	chain := sim.NewSimulator(sim.DefaultConfig())
+	c, err := core.New(core.WithBackend(chain), core.WithGasLimit(40000), core.WithConfirmTimeout(time.Second),
+		core.WithGasEstimation())
+	if err != nil {
+		t.Fatalf("New error: %s", err.Error())
+	}
+	if c.GasLimit != 40000 || c.ConfirmTimeout != time.Second || c.DefAcc != nil || !c.GasEstimation {
+		t.Fatalf("unexpected core: %+v", c)
+	}
+	if _, err = c.GetGlobalParam(); err != nil {
+		t.Fatalf("GetGlobalParam error: %s", err.Error())
+	}
+
+	_, err = core.New(core.WithWallet("./no-such-wallet.dat"), core.WithPassword([]byte("pwd")))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a missing wallet error, got %v", err)
+	}
+	errPrompt := errors.New("no terminal")
+	_, err = core.New(core.WithWallet("./no-such-wallet.dat"), core.WithPasswordFunc(func() ([]byte, error) {
+		return nil, errPrompt
+	}))
+	if !errors.Is(err, errPrompt) {
+		t.Fatalf("expected the password source error, got %v", err)
+	}
+}
+
+func TestNew_Network(t *testing.T) {
+	if network, ok := core.NetworkByName("local"); !ok || network.GasPrice != 0 {
+		t.Fatalf("unexpected local network: %+v", network)
+	}
+
+	cfg := sim.DefaultConfig()
+	cfg.ContractAddress = ccom.AddressFromVmCode([]byte("devnet ontfs"))
+	chain := sim.NewSimulator(cfg)
+	local, err := core.New(core.WithNetwork(core.LocalNet()), core.WithBackend(chain),
+		core.WithContractAddress(cfg.ContractAddress))
+	if err != nil {
+		t.Fatalf("New error: %s", err.Error())
+	}
+	if local.GasPrice != core.LocalNet().GasPrice || local.OntRpcSrvAddr != core.LocalNet().RPCAddrs[0] {
+		t.Fatalf("network settings not applied: %+v", local)
+	}
+	if _, err = local.GetGlobalParam(); err != nil {
+		t.Fatalf("GetGlobalParam error: %s", err.Error())
+	}
+
+	native, err := core.New(core.WithNetwork(core.LocalNet()), core.WithBackend(chain))
+	if err != nil {
+		t.Fatalf("New error: %s", err.Error())
+	}
+	if _, err = native.GetGlobalParam(); err == nil {
+		t.Fatalf("native contract found on a devnet deployment")
+	}
+}
@@ -0,0 +1,292 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// FeeBreakdown itemizes the ONG charged for a file or a space, in the
+// smallest unit as the contract counts it.
+type FeeBreakdown struct {
+	// StorageFee is the fee rate of the global parameters times the blocks,
+	// copies and seconds of storage.
+	StorageFee uint64
+	// PdpFee pays the contract invocations of the proofs of every copy.
+	PdpFee uint64
+	// Paid is what was paid for the file or the space before a renewal or an
+	// update, deducted from the fees. It is never more than their sum.
+	Paid uint64
+	// GasFee is the gas price times the gas of the transactions.
+	GasFee uint64
+}
+
+// Total returns the ONG charged.
+func (f FeeBreakdown) Total() uint64 {
+	return f.StorageFee + f.PdpFee - f.Paid + f.GasFee
+}
+
+func (f *FeeBreakdown) add(other FeeBreakdown) {
+	f.StorageFee += other.StorageFee
+	f.PdpFee += other.PdpFee
+	f.Paid += other.Paid
+	f.GasFee += other.GasFee
+}
+
+// deduct sets the amount already paid, bounded by the fees.
+func (f *FeeBreakdown) deduct(paid uint64) {
+	if fee := f.StorageFee + f.PdpFee; paid > fee {
+		paid = fee
+	}
+	f.Paid = paid
+}
+
+// PdpCount returns the proofs each copy of a file stored from timeStart to
+// timeExpired needs at pdpInterval, the first one included.
+func PdpCount(timeStart, timeExpired, pdpInterval uint64) uint64 {
+	if pdpInterval == 0 || timeExpired < timeStart {
+		return 1
+	}
+	return (timeExpired-timeStart)/pdpInterval + 1
+}
+
+// BlockCount returns the blocks taking sizeKB.
+func BlockCount(sizeKB uint64) uint64 {
+	return (sizeKB + common.FILE_BLOCK_SIZE_KB - 1) / common.FILE_BLOCK_SIZE_KB
+}
+
+// FileFee returns the fees of storing copyNumber copies of a file of
+// blockCount blocks, proved at pdpInterval, from timeStart to timeExpired in
+// seconds. The duration of the storage is timeExpired - timeStart.
+func FileFee(global *fs.FsGlobalParam, blockCount, copyNumber, pdpInterval, timeStart,
+	timeExpired uint64) FeeBreakdown {
+	return storageFee(global.FilePerBlockFeeRate, global, blockCount, copyNumber, pdpInterval, timeStart, timeExpired)
+}
+
+// SpaceFee is FileFee for a space of volumeKB, charged at the space fee rate.
+func SpaceFee(global *fs.FsGlobalParam, volumeKB, copyNumber, pdpInterval, timeStart,
+	timeExpired uint64) FeeBreakdown {
+	return storageFee(global.SpacePerBlockFeeRate, global, BlockCount(volumeKB), copyNumber, pdpInterval,
+		timeStart, timeExpired)
+}
+
+func storageFee(feeRate uint64, global *fs.FsGlobalParam, blockCount, copyNumber, pdpInterval, timeStart,
+	timeExpired uint64) FeeBreakdown {
+	var duration uint64
+	if timeExpired > timeStart {
+		duration = timeExpired - timeStart
+	}
+	return FeeBreakdown{
+		StorageFee: blockCount * copyNumber * duration * feeRate,
+		PdpFee:     copyNumber * PdpCount(timeStart, timeExpired, pdpInterval) * global.ContractInvokeGasFee,
+	}
+}
+
+// RenewFee returns the fees of renewing file until timeExpired: those of
+// storing it from its TimeStart to timeExpired, less its PayAmount.
+func RenewFee(global *fs.FsGlobalParam, file *fs.FileInfo, timeExpired uint64) FeeBreakdown {
+	fee := FileFee(global, file.FileBlockCount, file.CopyNumber, file.PdpInterval, file.TimeStart, timeExpired)
+	fee.deduct(file.PayAmount)
+	return fee
+}
+
+// UpdateSpaceFee returns the fees of updating space to volumeKB until
+// timeExpired, less its PayAmount.
+func UpdateSpaceFee(global *fs.FsGlobalParam, space *fs.SpaceInfo, volumeKB, timeExpired uint64) FeeBreakdown {
+	fee := SpaceFee(global, volumeKB, space.CopyNumber, space.PdpInterval, space.TimeStart, timeExpired)
+	fee.deduct(space.PayAmount)
+	return fee
+}
+
+// CostItem is the fee of one file, or of the space, of a CostEstimate.
+type CostItem struct {
+	// FileHash is empty for a space.
+	FileHash string
+	Fee      FeeBreakdown
+	// Err tells why the file is left out of the estimate.
+	Err error
+}
+
+// CostEstimate is the ONG a transaction would charge, before it is
+// submitted. Storage starts when the estimate is made, a little earlier than
+// the block time the contract counts from.
+type CostEstimate struct {
+	// Items are in the order of the input.
+	Items []CostItem
+	// Gas lists the pre-executed transactions. It is empty when the backend
+	// cannot pre-execute, and the gas fee is then that of GasLimit.
+	Gas []GasEstimate
+	// Fee sums the fees of the items without Err and the gas fee.
+	Fee FeeBreakdown
+}
+
+func (e *CostEstimate) addItem(item CostItem) {
+	e.Items = append(e.Items, item)
+	if item.Err == nil {
+		e.Fee.add(item.Fee)
+	}
+}
+
+// EstimateStoreFilesCost estimates the cost of StoreFiles(filesInfo). Files
+// stored in a space are paid by the space and cost only gas.
+func (c *Core) EstimateStoreFilesCost(ctx context.Context, filesInfo []common.FileStore) (*CostEstimate, error) {
+	global, err := c.GetGlobalParamContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := c.chainTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	estimate := &CostEstimate{}
+	for _, file := range filesInfo {
+		item := CostItem{FileHash: file.FileHash}
+		if file.StorageType != fs.FileStorageTypeUseSpace {
+			item.Fee = FileFee(global, file.FileBlockCount, file.CopyNumber, file.PdpInterval, now, file.TimeExpired)
+		}
+		estimate.addItem(item)
+	}
+	if len(filesInfo) == 0 {
+		return estimate, nil
+	}
+
+	params, err := c.batchParams(ctx, storeBatch(filesInfo), func(start, end int) ([]byte, error) {
+		return c.storeFilesParam(filesInfo[start:end])
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = c.estimateGasFee(ctx, estimate, fs.FS_STORE_FILES, params); err != nil {
+		return nil, err
+	}
+	return estimate, nil
+}
+
+// EstimateRenewFilesCost estimates the cost of RenewFiles(filesRenew). The
+// files the contract would refuse to renew, stored in a space or not expiring
+// before their RenewTime, are left out with an Err.
+func (c *Core) EstimateRenewFilesCost(ctx context.Context, filesRenew []common.FileRenew) (*CostEstimate, error) {
+	global, err := c.GetGlobalParamContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	estimate := &CostEstimate{}
+	var renewed []common.FileRenew
+	for _, fileRenew := range filesRenew {
+		item := CostItem{FileHash: fileRenew.FileHash}
+		fileInfo, err := c.GetFileInfoContext(ctx, fileRenew.FileHash)
+		switch {
+		case err != nil:
+			item.Err = err
+		case fileInfo.StorageType == fs.FileStorageTypeUseSpace:
+			item.Err = errors.New("file is stored in a space")
+		case fileRenew.RenewTime <= fileInfo.TimeExpired:
+			item.Err = errors.New("file does not expire before the renew time")
+		default:
+			item.Fee = RenewFee(global, fileInfo, fileRenew.RenewTime)
+			renewed = append(renewed, fileRenew)
+		}
+		estimate.addItem(item)
+	}
+	if len(renewed) == 0 {
+		return estimate, nil
+	}
+
+	params, err := c.batchParams(ctx, renewBatch(renewed), func(start, end int) ([]byte, error) {
+		return c.renewFilesParam(renewed[start:end])
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = c.estimateGasFee(ctx, estimate, fs.FS_RENEW_FILES, params); err != nil {
+		return nil, err
+	}
+	return estimate, nil
+}
+
+// EstimateRenewTo estimates the cost of renewing fileHashes, or every file of
+// the wallet when nil, until timeExpired.
+func (c *Core) EstimateRenewTo(ctx context.Context, fileHashes []string, timeExpired uint64) (*CostEstimate, error) {
+	if fileHashes == nil {
+		fileList, err := c.GetFileListContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, fileHash := range fileList.FilesH {
+			fileHashes = append(fileHashes, string(fileHash.FHash))
+		}
+	}
+	filesRenew := make([]common.FileRenew, len(fileHashes))
+	for i, fileHash := range fileHashes {
+		filesRenew[i] = common.FileRenew{FileHash: fileHash, RenewTime: timeExpired}
+	}
+	return c.EstimateRenewFilesCost(ctx, filesRenew)
+}
+
+// EstimateCreateSpaceCost estimates the cost of CreateSpace with the same
+// arguments.
+func (c *Core) EstimateCreateSpaceCost(ctx context.Context, volume uint64, copyNumber uint64, pdpInterval uint64,
+	timeExpired uint64) (*CostEstimate, error) {
+	global, err := c.GetGlobalParamContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := c.chainTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	estimate := &CostEstimate{}
+	estimate.addItem(CostItem{Fee: SpaceFee(global, volume, copyNumber, pdpInterval, now, timeExpired)})
+	param, err := c.createSpaceParam(volume, copyNumber, pdpInterval, timeExpired)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.estimateGasFee(ctx, estimate, fs.FS_CREATE_SPACE, [][]byte{param}); err != nil {
+		return nil, err
+	}
+	return estimate, nil
+}
+
+// EstimateUpdateSpaceCost estimates the cost of UpdateSpace with the same
+// arguments.
+func (c *Core) EstimateUpdateSpaceCost(ctx context.Context, volume uint64, timeExpired uint64) (*CostEstimate, error) {
+	global, err := c.GetGlobalParamContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	space, err := c.GetSpaceInfoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	estimate := &CostEstimate{}
+	estimate.addItem(CostItem{Fee: UpdateSpaceFee(global, space, volume, timeExpired)})
+	param, err := c.updateSpaceParam(volume, timeExpired)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.estimateGasFee(ctx, estimate, fs.FS_UPDATE_SPACE, [][]byte{param}); err != nil {
+		return nil, err
+	}
+	return estimate, nil
+}
+
+// estimateGasFee adds the gas fee of the transactions of method with params
+// to estimate, measured by EstimateGas. When the backend cannot pre-execute,
+// each of the transactions is charged GasLimit.
+func (c *Core) estimateGasFee(ctx context.Context, estimate *CostEstimate, method string, params [][]byte) error {
+	if !c.canPreExec(c.signer()) {
+		estimate.Fee.GasFee = uint64(len(params)) * c.GasLimit * c.GasPrice
+		return nil
+	}
+	for _, param := range params {
+		gas, err := c.EstimateGas(ctx, method, []interface{}{param})
+		if err != nil {
+			return err
+		}
+		estimate.Gas = append(estimate.Gas, *gas)
+		estimate.Fee.GasFee += gas.Gas * c.GasPrice
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+func TestFileFee(t *testing.T) {
+	global := &fs.FsGlobalParam{FilePerBlockFeeRate: 10, SpacePerBlockFeeRate: 20, ContractInvokeGasFee: 1000}
+
+	// 4 blocks, 2 copies, 3600s at 10 per block and second, 7 proofs per copy
+	fee := core.FileFee(global, 4, 2, 600, 1000, 4600)
+	if fee.StorageFee != 288000 || fee.PdpFee != 14000 || fee.Total() != 302000 {
+		t.Fatalf("unexpected file fee: %+v", fee)
+	}
+	// 1000KB takes 4 blocks of 256KB
+	fee = core.SpaceFee(global, 1000, 1, 3600, 1000, 4600)
+	if fee.StorageFee != 288000 || fee.PdpFee != 2000 || fee.Total() != 290000 {
+		t.Fatalf("unexpected space fee: %+v", fee)
+	}
+
+	file := &fs.FileInfo{FileBlockCount: 4, CopyNumber: 2, PdpInterval: 600, TimeStart: 1000, PayAmount: 302000}
+	fee = core.RenewFee(global, file, 8200)
+	if fee.StorageFee != 576000 || fee.PdpFee != 26000 || fee.Paid != 302000 || fee.Total() != 300000 {
+		t.Fatalf("unexpected renew fee: %+v", fee)
+	}
+	if fee = core.RenewFee(global, file, 1000); fee.Total() != 0 {
+		t.Fatalf("unexpected renew fee to an earlier date: %+v", fee)
+	}
+
+	// 2000KB takes 8 blocks, for 7200s at 20, and 3 proofs
+	space := &fs.SpaceInfo{CopyNumber: 1, PdpInterval: 3600, TimeStart: 1000, PayAmount: 290000}
+	fee = core.UpdateSpaceFee(global, space, 2000, 8200)
+	if fee.StorageFee != 1152000 || fee.PdpFee != 3000 || fee.Total() != 865000 {
+		t.Fatalf("unexpected space update fee: %+v", fee)
+	}
+
+	if core.PdpCount(1000, 4600, 600) != 7 || core.PdpCount(1000, 4600, 0) != 1 || core.PdpCount(4600, 1000, 600) != 1 {
+		t.Fatalf("unexpected pdp counts")
+	}
+	if core.BlockCount(1024) != 4 || core.BlockCount(1025) != 5 || core.BlockCount(0) != 0 {
+		t.Fatalf("unexpected block counts")
+	}
+}
+
+func TestCore_EstimateCost(t *testing.T) {
+	chain, c := newSimCore(t, false)
+	c.GasPrice = 500
+	ctx := context.Background()
+	global, err := c.GetGlobalParam()
+	if err != nil {
+		t.Fatalf("GetGlobalParam error: %s", err.Error())
+	}
+
+	file := common.FileStore{
+		FileHash:       "CostFile",
+		FileBlockCount: 4,
+		CopyNumber:     2,
+		PdpInterval:    600,
+		TimeExpired:    1577836800 + 3*3600,
+		StorageType:    fs.FileStorageTypeUseFile,
+	}
+	estimate, err := c.EstimateStoreFilesCost(ctx, []common.FileStore{file})
+	if err != nil {
+		t.Fatalf("EstimateStoreFilesCost error: %s", err.Error())
+	}
+	// stored from the time of the current block
+	duration := file.TimeExpired - chain.Timestamp()
+	if len(estimate.Items) != 1 || estimate.Fee.StorageFee != 4*2*duration*global.FilePerBlockFeeRate ||
+		estimate.Fee.PdpFee != 2*(duration/600+1)*global.ContractInvokeGasFee ||
+		len(estimate.Gas) != 1 || estimate.Fee.GasFee != estimate.Gas[0].Gas*500 ||
+		estimate.Fee.Total() != estimate.Items[0].Fee.Total()+estimate.Fee.GasFee {
+		t.Fatalf("unexpected store estimate: %+v", estimate)
+	}
+
+	if _, err, _ = c.StoreFiles([]common.FileStore{file}); err != nil {
+		t.Fatalf("StoreFiles error: %s", err.Error())
+	}
+	stored, err := c.GetFileInfo(file.FileHash)
+	if err != nil {
+		t.Fatalf("GetFileInfo error: %s", err.Error())
+	}
+
+	renewTo := file.TimeExpired + 24*3600
+	estimate, err = c.EstimateRenewTo(ctx, []string{file.FileHash, "MissingFile"}, renewTo)
+	if err != nil {
+		t.Fatalf("EstimateRenewTo error: %s", err.Error())
+	}
+	if len(estimate.Items) != 2 || estimate.Items[0].Err != nil || estimate.Items[1].Err == nil {
+		t.Fatalf("unexpected renew estimate: %+v", estimate)
+	}
+	// a renewal pays the storage from the start, less what was paid
+	duration = renewTo - stored.TimeStart
+	renewFee := estimate.Items[0].Fee
+	if renewFee.StorageFee != 4*2*duration*global.FilePerBlockFeeRate ||
+		renewFee.PdpFee != 2*(duration/600+1)*global.ContractInvokeGasFee || renewFee.Paid != stored.PayAmount {
+		t.Fatalf("unexpected renew fee: %+v", renewFee)
+	}
+
+	// 1000KB takes 4 blocks
+	estimate, err = c.EstimateCreateSpaceCost(ctx, 1000, 1, 600, file.TimeExpired)
+	if err != nil {
+		t.Fatalf("EstimateCreateSpaceCost error: %s", err.Error())
+	}
+	duration = file.TimeExpired - chain.Timestamp()
+	if estimate.Fee.StorageFee != 4*duration*global.SpacePerBlockFeeRate || estimate.Fee.GasFee == 0 {
+		t.Fatalf("unexpected space estimate: %+v", estimate)
+	}
+	var rejected *core.ContractRejectedError
+	if _, err = c.EstimateCreateSpaceCost(ctx, 1000, 1, 600, 1577836800); !errors.As(err, &rejected) {
+		t.Fatalf("expected the contract to reject an expired space, got %v", err)
+	}
+}
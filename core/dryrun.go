@@ -0,0 +1,60 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// DryRunResult is what the contract answered to a pre-executed invocation
+// that was not broadcast.
+type DryRunResult struct {
+	Method string
+	// Succeeded is false when the contract failed the invocation as a whole.
+	Succeeded bool
+	// Ret is the decoded return value of a successful invocation.
+	Ret *fs.RetInfo
+	// Errors is set for the batch file methods.
+	Errors   *fs.Errors
+	Gas      uint64
+	GasLimit uint64
+}
+
+// DryRun pre-executes a contract invocation signed by the signer of c,
+// without broadcasting it, and returns the answer of the contract.
+func (c *Core) DryRun(ctx context.Context, method string, params []interface{}) (*DryRunResult, error) {
+	signer := c.signer()
+	if signer == nil {
+		return nil, &NoSignerError{Method: method}
+	}
+	if !c.canPreExec(signer) {
+		return nil, errors.New(method + " backend cannot pre-execute signed invocations")
+	}
+	ret, err := c.preExecSigned(ctx, signer, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DryRunResult{
+		Method:    method,
+		Succeeded: ret.State != txStateFailed,
+		Gas:       ret.Gas,
+		GasLimit:  c.gasWithMargin(ret.Gas),
+	}
+	if result.Succeeded {
+		if ret.Result != nil {
+			data, err := ret.Result.ToByteArray()
+			if err != nil {
+				return nil, &RPCError{Method: method, Err: err}
+			}
+			result.Ret = fs.DecRet(data)
+		}
+		if isBatchMethod(method) {
+			if result.Errors, err = batchErrors(c.contractAddress(), ret.Notify); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,519 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+const (
+	defaultEndpointCheckInterval = 10 * time.Second
+	defaultEndpointMaxLag        = uint32(5)
+	defaultEndpointStallTimeout  = 2 * time.Minute
+)
+
+// Endpoint is one Ontology node of an EndpointPool.
+type Endpoint struct {
+	Addr    string
+	Backend ChainBackend
+}
+
+// EndpointPoolConfig controls the health checks of an EndpointPool. Zero
+// values select the defaults.
+type EndpointPoolConfig struct {
+	// CheckInterval is the period of the health checks, 10s by default.
+	CheckInterval time.Duration
+	// MaxLatency is the slowest answer to a health check accepted from a
+	// healthy endpoint. Zero accepts any latency.
+	MaxLatency time.Duration
+	// MaxLag is the number of blocks an endpoint may be behind the highest
+	// one and still be healthy, 5 by default.
+	MaxLag uint32
+	// StallTimeout is how long an endpoint may go without a new block and
+	// still be healthy, 2 minutes by default.
+	StallTimeout time.Duration
+	// SpreadReads spreads the pre-executed queries across the healthy
+	// endpoints instead of sending them to the primary one.
+	SpreadReads bool
+}
+
+// EndpointStatus is the outcome of the latest health check of an endpoint.
+// Err tells why an endpoint is unhealthy.
+type EndpointStatus struct {
+	Addr      string
+	Healthy   bool
+	Height    uint32
+	Latency   time.Duration
+	Err       error
+	CheckedAt time.Time
+}
+
+type endpoint struct {
+	Endpoint
+	healthy    bool
+	height     uint32
+	latency    time.Duration
+	err        error
+	checkedAt  time.Time
+	progressAt time.Time
+}
+
+// EndpointPool is a ChainBackend spread over several nodes. Calls go to the
+// primary endpoint, the first healthy one in configuration order, and fail
+// over to the next healthy ones on a transport error, as told by
+// IsTransientError. When every endpoint is unhealthy they are all tried
+// anyway. An answer of a node, such as a rejected transaction or a missing
+// result, is returned as is without trying the other endpoints.
+//
+// A transaction is built and signed once, then the same transaction is sent
+// to the next endpoint when sending fails: all endpoints see one transaction
+// hash, which the chain executes at most once, and a duplicate refused by the
+// next endpoint was taken by a previous one. Failover of broadcasts needs
+// endpoints implementing TxBackend; other endpoints only get broadcasts on the
+// primary.
+type EndpointPool struct {
+	// Logger receives the endpoints becoming unhealthy, DefaultLogger when
+	// nil.
+	Logger Logger
+
+	cfg       EndpointPoolConfig
+	lock      sync.Mutex
+	endpoints []*endpoint
+	next      uint32
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewEndpointPool(cfg EndpointPoolConfig, endpoints ...Endpoint) (*EndpointPool, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("endpoint pool needs at least one endpoint")
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = defaultEndpointCheckInterval
+	}
+	if cfg.MaxLag == 0 {
+		cfg.MaxLag = defaultEndpointMaxLag
+	}
+	if cfg.StallTimeout <= 0 {
+		cfg.StallTimeout = defaultEndpointStallTimeout
+	}
+	p := &EndpointPool{
+		cfg:     cfg,
+		closeCh: make(chan struct{}),
+	}
+	for _, e := range endpoints {
+		p.endpoints = append(p.endpoints, &endpoint{Endpoint: e, healthy: true})
+	}
+	return p, nil
+}
+
+// Start runs a health check now and then every CheckInterval.
+func (p *EndpointPool) Start() {
+	p.Check()
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.cfg.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.closeCh:
+				return
+			case <-ticker.C:
+				p.Check()
+			}
+		}
+	}()
+}
+
+// Close stops the health checks. It may be called more than once.
+func (p *EndpointPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closeCh)
+	})
+	p.wg.Wait()
+}
+
+// Status returns the health of every endpoint, in configuration order.
+func (p *EndpointPool) Status() []EndpointStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	status := make([]EndpointStatus, len(p.endpoints))
+	for i, e := range p.endpoints {
+		status[i] = EndpointStatus{
+			Addr:      e.Addr,
+			Healthy:   e.healthy,
+			Height:    e.height,
+			Latency:   e.latency,
+			Err:       e.err,
+			CheckedAt: e.checkedAt,
+		}
+	}
+	return status
+}
+
+// Check queries the current block of every endpoint and updates their
+// health. An endpoint that cannot report its block is healthy until a call
+// to it fails.
+func (p *EndpointPool) Check() {
+	p.lock.Lock()
+	wasHealthy := make([]bool, len(p.endpoints))
+	for i, e := range p.endpoints {
+		wasHealthy[i] = e.healthy
+	}
+	p.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			p.check(e)
+		}(e)
+	}
+	wg.Wait()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := time.Now()
+	var best uint32
+	for _, e := range p.endpoints {
+		if e.err == nil && e.height > best {
+			best = e.height
+		}
+	}
+	for i, e := range p.endpoints {
+		if e.err != nil {
+			p.logHealth(e, wasHealthy[i])
+			continue
+		}
+		switch {
+		case e.height == 0:
+			// no block reported yet
+		case p.cfg.MaxLatency > 0 && e.latency > p.cfg.MaxLatency:
+			e.err = fmt.Errorf("latency %s exceeds %s", e.latency, p.cfg.MaxLatency)
+		case best-e.height > p.cfg.MaxLag:
+			e.err = fmt.Errorf("%d blocks behind", best-e.height)
+		case now.Sub(e.progressAt) > p.cfg.StallTimeout:
+			e.err = fmt.Errorf("no new block since %s", e.progressAt.Format(time.RFC3339))
+		}
+		e.healthy = e.err == nil
+		p.logHealth(e, wasHealthy[i])
+	}
+}
+
+// logHealth logs the health of e when it changed.
+func (p *EndpointPool) logHealth(e *endpoint, wasHealthy bool) {
+	switch {
+	case wasHealthy && e.err != nil:
+		p.logger().Warn("endpoint unhealthy", F("endpoint", e.Addr), ErrField(e.err))
+	case !wasHealthy && e.err == nil:
+		p.logger().Info("endpoint healthy", F("endpoint", e.Addr), F("height", e.height))
+	}
+}
+
+func (p *EndpointPool) check(e *endpoint) {
+	blocks, ok := e.Backend.(BlockBackend)
+	if !ok {
+		p.lock.Lock()
+		e.healthy, e.err, e.checkedAt = true, nil, time.Now()
+		p.lock.Unlock()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.CheckInterval)
+	defer cancel()
+	start := time.Now()
+	var height uint32
+	err := callContext(ctx, func() error {
+		var err error
+		height, err = blocks.GetCurrentBlockHeight()
+		return err
+	})
+	now := time.Now()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	e.checkedAt = now
+	e.latency = now.Sub(start)
+	e.err = err
+	if err != nil {
+		e.healthy = false
+		return
+	}
+	if height > e.height {
+		e.height = height
+		e.progressAt = now
+	}
+}
+
+// order returns the healthy endpoints, from the primary one or, when spread
+// is set, from the next one in turn, followed by the unhealthy endpoints.
+func (p *EndpointPool) order(spread bool) []*endpoint {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var healthy, unhealthy []*endpoint
+	for _, e := range p.endpoints {
+		if e.healthy {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	if spread && len(healthy) > 1 {
+		start := int(atomic.AddUint32(&p.next, 1) % uint32(len(healthy)))
+		healthy = append(healthy[start:], healthy[:start]...)
+	}
+	return append(healthy, unhealthy...)
+}
+
+type endpointFailure struct {
+	endpoint *endpoint
+	err      error
+}
+
+// markFailed makes the failed endpoints unhealthy until the next health
+// check.
+func (p *EndpointPool) markFailed(failures []endpointFailure) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, failure := range failures {
+		if failure.endpoint.healthy {
+			p.logger().Warn("endpoint failed over", F("endpoint", failure.endpoint.Addr),
+				ErrField(failure.err))
+		}
+		failure.endpoint.healthy = false
+		failure.endpoint.err = failure.err
+	}
+}
+
+func (p *EndpointPool) logger() Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+	return DefaultLogger()
+}
+
+// unsupportedError is returned for an endpoint that does not implement the
+// interface a call needs. The call moves on to the next endpoint without
+// failing this one.
+type unsupportedError string
+
+func (e unsupportedError) Error() string {
+	return "endpoint cannot " + string(e)
+}
+
+// call runs fn on the endpoints in turn until one of them answers, and
+// returns the error of the first endpoint when none does. Only transport
+// errors move on to the next endpoint: any other error is the answer of the
+// node. The endpoints that failed are only marked unhealthy when another one
+// answered, as a transport error returned by every endpoint may be caused by
+// the request.
+func (p *EndpointPool) call(spread bool, fn func(backend ChainBackend) error) error {
+	var failures []endpointFailure
+	var firstErr error
+	for _, e := range p.order(spread) {
+		err := fn(e.Backend)
+		var unsupported unsupportedError
+		switch {
+		case err == nil:
+			p.markFailed(failures)
+			return nil
+		case errors.As(err, &unsupported):
+		case IsTransientError(err):
+			failures = append(failures, endpointFailure{endpoint: e, err: err})
+		default:
+			p.markFailed(failures)
+			return err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (p *EndpointPool) primary() ChainBackend {
+	return p.order(false)[0].Backend
+}
+
+func (p *EndpointPool) InvokeNativeContract(gasPrice, gasLimit uint64, signer Signer, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (ccom.Uint256, error) {
+	primary := p.primary()
+	builder, ok := primary.(TxBackend)
+	if !ok {
+		// the transaction is built by the backend, so it cannot be resent
+		return primary.InvokeNativeContract(gasPrice, gasLimit, signer, version, contractAddress, method, params)
+	}
+	tx, err := builder.NewNativeInvokeTransaction(gasPrice, gasLimit, version, contractAddress, method, params)
+	if err != nil {
+		return ccom.UINT256_EMPTY, err
+	}
+	if err = signer.SignTransaction(tx); err != nil {
+		return ccom.UINT256_EMPTY, err
+	}
+	return p.SendTransaction(tx)
+}
+
+func (p *EndpointPool) SendTransaction(tx *types.MutableTransaction) (ccom.Uint256, error) {
+	var txHash ccom.Uint256
+	var sent bool
+	err := p.call(false, func(backend ChainBackend) error {
+		sender, ok := backend.(TxBackend)
+		if !ok {
+			return unsupportedError("send transactions")
+		}
+		var err error
+		txHash, err = sender.SendTransaction(tx)
+		if err != nil && sent && isDuplicateTxError(err) {
+			// a previous endpoint took the transaction before failing
+			txHash, err = tx.Hash(), nil
+		}
+		sent = true
+		return err
+	})
+	return txHash, err
+}
+
+func (p *EndpointPool) NewNativeInvokeTransaction(gasPrice, gasLimit uint64, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (*types.MutableTransaction, error) {
+	builder, ok := p.primary().(TxBackend)
+	if !ok {
+		return nil, unsupportedError("build transactions")
+	}
+	return builder.NewNativeInvokeTransaction(gasPrice, gasLimit, version, contractAddress, method, params)
+}
+
+func (p *EndpointPool) PreExecTransaction(tx *types.MutableTransaction) (*sdkcom.PreExecResult, error) {
+	var ret *sdkcom.PreExecResult
+	err := p.call(p.cfg.SpreadReads, func(backend ChainBackend) error {
+		executor, ok := backend.(TxBackend)
+		if !ok {
+			return unsupportedError("pre-execute transactions")
+		}
+		var err error
+		ret, err = executor.PreExecTransaction(tx)
+		return err
+	})
+	return ret, err
+}
+
+func (p *EndpointPool) PreExecInvokeNativeContract(contractAddress ccom.Address, version byte, method string,
+	params []interface{}) (*sdkcom.PreExecResult, error) {
+	var ret *sdkcom.PreExecResult
+	err := p.call(p.cfg.SpreadReads, func(backend ChainBackend) error {
+		var err error
+		ret, err = backend.PreExecInvokeNativeContract(contractAddress, version, method, params)
+		return err
+	})
+	return ret, err
+}
+
+func (p *EndpointPool) PreExecSignedNativeContract(gasPrice, gasLimit uint64, signer Signer, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (*sdkcom.PreExecResult, error) {
+	var ret *sdkcom.PreExecResult
+	err := p.call(p.cfg.SpreadReads, func(backend ChainBackend) error {
+		estimator, ok := backend.(EstimateBackend)
+		if !ok {
+			return unsupportedError("pre-execute signed invocations")
+		}
+		var err error
+		ret, err = estimator.PreExecSignedNativeContract(gasPrice, gasLimit, signer, version, contractAddress,
+			method, params)
+		return err
+	})
+	return ret, err
+}
+
+func (p *EndpointPool) GetBlockHeightByTxHash(txHash string) (uint32, error) {
+	var height uint32
+	err := p.call(false, func(backend ChainBackend) error {
+		var err error
+		height, err = backend.GetBlockHeightByTxHash(txHash)
+		return err
+	})
+	return height, err
+}
+
+// GetSmartContractEvent also asks the next endpoints when one has no event for
+// txHash, as it may not have the block of txHash yet. It returns the error of
+// a node only when no node has the event.
+func (p *EndpointPool) GetSmartContractEvent(txHash string) (*sdkcom.SmartContactEvent, error) {
+	var failures []endpointFailure
+	var answerErr error
+	answered := false
+	for _, e := range p.order(false) {
+		event, err := e.Backend.GetSmartContractEvent(txHash)
+		switch {
+		case err == nil && event != nil:
+			p.markFailed(failures)
+			return event, nil
+		case err == nil:
+			answered = true
+		case IsTransientError(err):
+			failures = append(failures, endpointFailure{endpoint: e, err: err})
+		case answerErr == nil:
+			answerErr = err
+		}
+	}
+	if answered || answerErr != nil {
+		p.markFailed(failures)
+	}
+	switch {
+	case answered:
+		return nil, nil
+	case answerErr != nil:
+		return nil, answerErr
+	}
+	return nil, failures[0].err
+}
+
+func (p *EndpointPool) GetCurrentBlockHeight() (uint32, error) {
+	var height uint32
+	err := p.call(false, func(backend ChainBackend) error {
+		blocks, ok := backend.(BlockBackend)
+		if !ok {
+			return unsupportedError("report blocks")
+		}
+		var err error
+		height, err = blocks.GetCurrentBlockHeight()
+		return err
+	})
+	return height, err
+}
+
+func (p *EndpointPool) GetBlockHash(height uint32) (ccom.Uint256, error) {
+	var hash ccom.Uint256
+	err := p.call(false, func(backend ChainBackend) error {
+		blocks, ok := backend.(BlockBackend)
+		if !ok {
+			return unsupportedError("report blocks")
+		}
+		var err error
+		hash, err = blocks.GetBlockHash(height)
+		return err
+	})
+	return hash, err
+}
+
+func (p *EndpointPool) GetBlockTimestamp(height uint32) (uint32, error) {
+	var timestamp uint32
+	err := p.call(false, func(backend ChainBackend) error {
+		blocks, ok := backend.(BlockTimeBackend)
+		if !ok {
+			return unsupportedError("report block times")
+		}
+		var err error
+		timestamp, err = blocks.GetBlockTimestamp(height)
+		return err
+	})
+	return timestamp, err
+}
@@ -0,0 +1,191 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontfs-contract-api/sim"
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+var errNodeDown = fmt.Errorf("node is down: %w", syscall.ECONNREFUSED)
+
+// nodeBackend is one node of a chain simulated by a shared Simulator.
+type nodeBackend struct {
+	*sim.Simulator
+
+	lock     sync.Mutex
+	down     bool
+	sendDown bool
+	// sendErr is the rejection of every broadcast when set
+	sendErr error
+	// lostReply makes broadcasts reach the chain and fail anyway
+	lostReply bool
+	sends     []ccom.Uint256
+	queries   int
+}
+
+func (b *nodeBackend) setDown(down bool, sendDown bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.down, b.sendDown = down, sendDown
+}
+
+func (b *nodeBackend) GetCurrentBlockHeight() (uint32, error) {
+	b.lock.Lock()
+	down := b.down
+	b.lock.Unlock()
+	if down {
+		return 0, errNodeDown
+	}
+	return b.Simulator.GetCurrentBlockHeight()
+}
+
+func (b *nodeBackend) PreExecInvokeNativeContract(contractAddress ccom.Address, version byte, method string,
+	params []interface{}) (*sdkcom.PreExecResult, error) {
+	b.lock.Lock()
+	b.queries++
+	down := b.down
+	b.lock.Unlock()
+	if down {
+		return nil, errNodeDown
+	}
+	return b.Simulator.PreExecInvokeNativeContract(contractAddress, version, method, params)
+}
+
+func (b *nodeBackend) SendTransaction(tx *types.MutableTransaction) (ccom.Uint256, error) {
+	b.lock.Lock()
+	b.sends = append(b.sends, tx.Hash())
+	down := b.down || b.sendDown
+	sendErr := b.sendErr
+	lostReply := b.lostReply
+	b.lock.Unlock()
+	if down {
+		return ccom.UINT256_EMPTY, errNodeDown
+	}
+	if sendErr != nil {
+		return ccom.UINT256_EMPTY, sendErr
+	}
+	if lostReply {
+		if _, err := b.Simulator.SendTransaction(tx); err != nil {
+			return ccom.UINT256_EMPTY, err
+		}
+		return ccom.UINT256_EMPTY, errNodeDown
+	}
+	return b.Simulator.SendTransaction(tx)
+}
+
+func TestEndpointPool_Failover(t *testing.T) {
+	chain := sim.NewSimulator(simConfig())
+	primary := &nodeBackend{Simulator: chain}
+	secondary := &nodeBackend{Simulator: chain}
+	pool, err := core.NewEndpointPool(core.EndpointPoolConfig{},
+		core.Endpoint{Addr: "primary", Backend: primary},
+		core.Endpoint{Addr: "secondary", Backend: secondary})
+	if err != nil {
+		t.Fatalf("NewEndpointPool error: %s", err.Error())
+	}
+	c := newTestCore(t, pool)
+
+	primary.setDown(true, false)
+	pool.Check()
+	if status := pool.Status(); status[0].Healthy || !status[1].Healthy {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if _, err = c.GetGlobalParam(); err != nil {
+		t.Fatalf("GetGlobalParam error: %s", err.Error())
+	}
+	if primary.queries != 0 || secondary.queries == 0 {
+		t.Fatalf("query sent to the unhealthy primary")
+	}
+
+	// the primary accepts queries but fails to broadcast: the transaction is
+	// resent unchanged to the secondary
+	primary.setDown(false, true)
+	pool.Check()
+	if _, err = c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	if len(primary.sends) != 1 || len(secondary.sends) != 1 || primary.sends[0] != secondary.sends[0] {
+		t.Fatalf("unexpected broadcasts: %v %v", primary.sends, secondary.sends)
+	}
+	if status := pool.Status(); status[0].Healthy {
+		t.Fatalf("primary is healthy after a failed broadcast")
+	}
+	if _, err = c.GetNodeInfo(c.WalletAddr); err != nil {
+		t.Fatalf("GetNodeInfo error: %s", err.Error())
+	}
+
+	// a rejected broadcast is an answer of the node: it is neither resent nor
+	// blamed on the primary
+	primary.setDown(false, false)
+	pool.Check()
+	primary.lock.Lock()
+	primary.sendErr = errors.New("duplicated transaction")
+	primary.sends, secondary.sends = nil, nil
+	primary.lock.Unlock()
+	if _, err = c.NodeUpdate(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err == nil {
+		t.Fatalf("NodeUpdate succeeded despite the rejection")
+	}
+	if len(primary.sends) != 1 || len(secondary.sends) != 0 {
+		t.Fatalf("rejected transaction resent: %v %v", primary.sends, secondary.sends)
+	}
+	if status := pool.Status(); !status[0].Healthy {
+		t.Fatalf("primary is unhealthy after a rejected broadcast")
+	}
+
+	// the primary takes the transaction but its reply is lost: the secondary
+	// refusing it as a duplicate means it was sent
+	primary.lock.Lock()
+	primary.sendErr, primary.lostReply = nil, true
+	primary.sends = nil
+	primary.lock.Unlock()
+	secondary.lock.Lock()
+	secondary.sendErr = errors.New("duplicated transaction")
+	secondary.lock.Unlock()
+	if _, err = c.NodeUpdate(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeUpdate error: %s", err.Error())
+	}
+	if len(primary.sends) != 1 || len(secondary.sends) != 1 || primary.sends[0] != secondary.sends[0] {
+		t.Fatalf("unexpected broadcasts: %v %v", primary.sends, secondary.sends)
+	}
+
+	pool.Close()
+	pool.Close()
+}
+
+func TestEndpointPool_SpreadReads(t *testing.T) {
+	chain := sim.NewSimulator(sim.DefaultConfig())
+	nodes := []*nodeBackend{{Simulator: chain}, {Simulator: chain}}
+	pool, err := core.NewEndpointPool(core.EndpointPoolConfig{SpreadReads: true},
+		core.Endpoint{Addr: "a", Backend: nodes[0]},
+		core.Endpoint{Addr: "b", Backend: nodes[1]})
+	if err != nil {
+		t.Fatalf("NewEndpointPool error: %s", err.Error())
+	}
+	c := core.InitWithBackend(pool, nil, 0, 20000)
+	for i := 0; i < 4; i++ {
+		if _, err = c.GetGlobalParam(); err != nil {
+			t.Fatalf("GetGlobalParam error: %s", err.Error())
+		}
+	}
+	if nodes[0].queries != 2 || nodes[1].queries != 2 {
+		t.Fatalf("queries not spread: %d %d", nodes[0].queries, nodes[1].queries)
+	}
+
+	// a contract error is not blamed on the endpoints
+	if _, err = c.GetNodeInfo(ccom.ADDRESS_EMPTY); err == nil {
+		t.Fatalf("GetNodeInfo of an unknown node succeeded")
+	}
+	for _, status := range pool.Status() {
+		if !status.Healthy {
+			t.Fatalf("endpoint %s marked unhealthy by a contract error", status.Addr)
+		}
+	}
+}
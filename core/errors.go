@@ -0,0 +1,133 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ccom "github.com/ontio/ontology/common"
+)
+
+// ErrNoSigner matches every NoSignerError through errors.Is.
+var ErrNoSigner = errors.New("no signing account")
+
+// ErrWalletPassword is matched by the error of New when the wallet account
+// cannot be unlocked with the password.
+var ErrWalletPassword = errors.New("wrong wallet password")
+
+// NoSignerError is returned when an operation needs an account to sign with
+// but the Core has none.
+type NoSignerError struct {
+	Method string
+}
+
+func (e *NoSignerError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Method, ErrNoSigner.Error())
+}
+
+func (e *NoSignerError) Is(target error) bool {
+	return target == ErrNoSigner
+}
+
+// PayerSignatureError is returned when a transaction must be co-signed by a
+// payer whose key is not held by the Core. Such transactions are submitted
+// through Export and SubmitTransaction.
+type PayerSignatureError struct {
+	Method string
+	Payer  ccom.Address
+}
+
+func (e *PayerSignatureError) Error() string {
+	return fmt.Sprintf("%s: payer %s must co-sign the exported transaction", e.Method, e.Payer.ToBase58())
+}
+
+// TxNotConfirmedError is returned when a submitted transaction was not seen in
+// a block within Timeout. Err is the cause, such as a context error when the
+// wait was cancelled. The transaction may still be confirmed later.
+type TxNotConfirmedError struct {
+	Method  string
+	TxHash  []byte
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *TxNotConfirmedError) Error() string {
+	return fmt.Sprintf("%s tx %s is not confirmed within %s: %v", e.Method, hexTxHash(e.TxHash), e.Timeout, e.Err)
+}
+
+func (e *TxNotConfirmedError) Unwrap() error {
+	return e.Err
+}
+
+// ContractRejectedError is returned when the ontfs contract refused an
+// invocation. TxHash is empty when the rejection came from a pre-execution.
+type ContractRejectedError struct {
+	Method  string
+	Message string
+	TxHash  []byte
+}
+
+func (e *ContractRejectedError) Error() string {
+	return fmt.Sprintf("%s rejected by contract: %s", e.Method, e.Message)
+}
+
+// RPCError is returned when the node could not be reached or failed to answer
+// a request. Err is the transport error.
+type RPCError struct {
+	Method string
+	Err    error
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s rpc error: %s", e.Method, e.Err.Error())
+}
+
+func (e *RPCError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned by the batch file methods when some of the
+// transactions a batch was split into could not be submitted or confirmed.
+// Err is the error of the first failed one; the BatchResult returned with it
+// reports every transaction.
+type BatchError struct {
+	Method string
+	Failed int
+	Chunks int
+	Err    error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%s: %d of %d transactions failed: %v", e.Method, e.Failed, e.Chunks, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// PlacementError is returned when a file cannot get its copies from the
+// registered nodes. File is set when the file itself cannot be placed,
+// otherwise Reasons counts the nodes rejected for each reason.
+type PlacementError struct {
+	FileHash string
+	Copies   uint64
+	File     PlacementReason
+	Eligible int
+	Nodes    int
+	Reasons  map[PlacementReason]int
+}
+
+func (e *PlacementError) Error() string {
+	if len(e.File) != 0 {
+		return fmt.Sprintf("file %s cannot be placed: %s", e.FileHash, e.File)
+	}
+	reasons := make([]string, 0, len(e.Reasons))
+	for _, reason := range nodePlacementReasons {
+		if n, ok := e.Reasons[reason]; ok {
+			reasons = append(reasons, fmt.Sprintf("%d %s", n, reason))
+		}
+	}
+	return fmt.Sprintf("file %s needs %d copies but %d of %d nodes qualify: %s", e.FileHash, e.Copies,
+		e.Eligible, e.Nodes, strings.Join(reasons, ", "))
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+const (
+	// minGasLimit is the lowest gas limit accepted by Ontology nodes.
+	minGasLimit             = uint64(20000)
+	preExecGasLimit         = uint64(200000000)
+	defaultGasMarginPercent = uint64(20)
+)
+
+// GasEstimate is the gas of one transaction, measured by pre-executing it.
+// GasLimit is Gas plus the safety margin of the Core.
+type GasEstimate struct {
+	Method   string
+	Gas      uint64
+	GasLimit uint64
+}
+
+// EstimateGas pre-executes a contract invocation signed by the signer of c,
+// without submitting it, and returns its gas.
+func (c *Core) EstimateGas(ctx context.Context, method string, params []interface{}) (*GasEstimate, error) {
+	signer := c.signer()
+	if signer == nil {
+		return nil, &NoSignerError{Method: method}
+	}
+	if !c.canPreExec(signer) {
+		return nil, errors.New(method + " backend cannot estimate gas")
+	}
+	return c.estimateGas(ctx, signer, method, params)
+}
+
+// gasLimit returns the gas limit to submit an invocation with. When
+// GasEstimation is set, it pre-executes the invocation if the backend
+// supports it.
+func (c *Core) gasLimit(ctx context.Context, signer Signer, method string, params []interface{}) (uint64, error) {
+	if !c.GasEstimation || !c.canPreExec(signer) {
+		return c.GasLimit, nil
+	}
+	estimate, err := c.estimateGas(ctx, signer, method, params)
+	if err != nil {
+		return 0, err
+	}
+	return estimate.GasLimit, nil
+}
+
+func (c *Core) estimateGas(ctx context.Context, signer Signer, method string,
+	params []interface{}) (*GasEstimate, error) {
+	ret, err := c.preExecSigned(ctx, signer, method, params)
+	if err == nil && ret.State == txStateFailed {
+		err = &ContractRejectedError{Method: method, Message: preExecFailure(ret)}
+	}
+	c.observePreExec(method, err)
+	if err != nil {
+		return nil, err
+	}
+	return &GasEstimate{
+		Method:   method,
+		Gas:      ret.Gas,
+		GasLimit: c.gasWithMargin(ret.Gas),
+	}, nil
+}
+
+// preExecFailure returns the message of a failed pre-execution, with the
+// error the contract returned when there is one.
+func preExecFailure(ret *sdkcom.PreExecResult) string {
+	const message = "pre-execution failed"
+	if ret.Result == nil {
+		return message
+	}
+	data, err := ret.Result.ToByteArray()
+	if err != nil || len(data) == 0 {
+		return message
+	}
+	if retInfo := fs.DecRet(data); !retInfo.Ret && len(retInfo.Info) != 0 {
+		return message + ": " + string(retInfo.Info)
+	}
+	return message
+}
+
+// preExecSigned pre-executes an invocation signed by signer, and by the payer
+// of c when it is another account.
+func (c *Core) preExecSigned(ctx context.Context, signer Signer, method string,
+	params []interface{}) (*sdkcom.PreExecResult, error) {
+	var ret *sdkcom.PreExecResult
+	err := c.read(ctx, method, func() error {
+		var err error
+		if c.thirdPartyPayer(signer) {
+			backend := c.Backend.(TxBackend)
+			tx, err := c.buildTransaction(backend, preExecGasLimit, signer, method, params)
+			if err != nil {
+				return err
+			}
+			ret, err = backend.PreExecTransaction(tx)
+			return err
+		}
+		ret, err = c.Backend.(EstimateBackend).PreExecSignedNativeContract(c.GasPrice, preExecGasLimit, signer,
+			c.ContractVersion, c.contractAddress(), method, params)
+		return err
+	})
+	return ret, err
+}
+
+func (c *Core) gasWithMargin(gas uint64) uint64 {
+	margin := c.GasMarginPercent
+	if margin == 0 {
+		margin = defaultGasMarginPercent
+	}
+	gasLimit := gas + gas*margin/100
+	if gasLimit < minGasLimit {
+		gasLimit = minGasLimit
+	}
+	return gasLimit
+}
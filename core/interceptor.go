@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	ccom "github.com/ontio/ontology/common"
+)
+
+// InvocationKind tells a query from a transaction.
+type InvocationKind int
+
+const (
+	// InvocationQuery is a pre-executed read-only method.
+	InvocationQuery InvocationKind = iota
+	// InvocationTransaction is a signed and broadcast method.
+	InvocationTransaction
+)
+
+func (k InvocationKind) String() string {
+	if k == InvocationQuery {
+		return "query"
+	}
+	return "transaction"
+}
+
+// Invocation is one call to the contract passed through the Interceptors of a
+// Core. An interceptor may change Params before calling the next one.
+//
+// Dry runs and exported transactions are not submitted and never reach the
+// interceptors. The transactions sent by SubmitTransaction were built and
+// signed elsewhere: their Params and Signer are not set.
+type Invocation struct {
+	Kind InvocationKind
+	// Method is the contract method, one of the fs.FS_* names.
+	Method string
+	Params []interface{}
+	// Signer and Payer are the signing and the gas paying accounts of a
+	// transaction.
+	Signer Signer
+	Payer  ccom.Address
+	// Result is the payload of a successful query, and TxHash the hash of a
+	// broadcast transaction.
+	Result []byte
+	TxHash []byte
+}
+
+// Invoker runs an Invocation, setting its Result or TxHash.
+type Invoker func(ctx context.Context, inv *Invocation) error
+
+// Interceptor wraps the Invoker next with cross-cutting behavior. It calls
+// next to proceed with inv, or returns an error to reject it.
+type Interceptor func(ctx context.Context, inv *Invocation, next Invoker) error
+
+// intercept runs invoke through the Interceptors of c, the first one being
+// the outermost.
+func (c *Core) intercept(ctx context.Context, inv *Invocation, invoke Invoker) error {
+	for i := len(c.Interceptors) - 1; i >= 0; i-- {
+		interceptor, next := c.Interceptors[i], invoke
+		invoke = func(ctx context.Context, inv *Invocation) error {
+			return interceptor(ctx, inv, next)
+		}
+	}
+	return invoke(ctx, inv)
+}
+
+// transactionInvocation returns the Invocation of a transaction signed by
+// signer with the account paying for it.
+func (c *Core) transactionInvocation(method string, params []interface{}, signer Signer) *Invocation {
+	inv := &Invocation{Kind: InvocationTransaction, Method: method, Params: params, Signer: signer}
+	if signer != nil && !c.thirdPartyPayer(signer) {
+		inv.Payer = signer.Address()
+	} else {
+		inv.Payer = c.payerAddress()
+	}
+	return inv
+}
+
+// Tracer starts spans in the manner of an OpenTelemetry trace.Tracer, which a
+// few lines can adapt to it.
+type Tracer interface {
+	// Start starts a span child of the span of ctx, if any, and returns a
+	// context holding the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(fields ...Field)
+	RecordError(err error)
+	End()
+}
+
+// TracingInterceptor records a span named "ontfs <method>" for every
+// invocation, with the kind, method, signer, payer and tx hash as attributes.
+func TracingInterceptor(tracer Tracer) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Invoker) error {
+		ctx, span := tracer.Start(ctx, "ontfs "+inv.Method)
+		defer span.End()
+		span.SetAttributes(F("ontfs.kind", inv.Kind.String()), F("ontfs.method", inv.Method))
+		if inv.Kind == InvocationTransaction {
+			if inv.Signer != nil {
+				span.SetAttributes(F("ontfs.signer", inv.Signer.Address().ToBase58()))
+			}
+			span.SetAttributes(F("ontfs.payer", inv.Payer.ToBase58()))
+		}
+		err := next(ctx, inv)
+		if len(inv.TxHash) != 0 {
+			span.SetAttributes(F("ontfs.tx_hash", hexTxHash(inv.TxHash)))
+		}
+		if err != nil {
+			span.RecordError(err)
+		}
+		return err
+	}
+}
+
+// TimingInterceptor logs the duration of every invocation to logger, or to
+// DefaultLogger when nil: at Debug level, or Warn when it fails.
+func TimingInterceptor(logger Logger) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Invoker) error {
+		start := time.Now()
+		err := next(ctx, inv)
+		l := logger
+		if l == nil {
+			l = DefaultLogger()
+		}
+		fields := []Field{MethodField(inv.Method), F("kind", inv.Kind.String()), F("elapsed", time.Since(start))}
+		if len(inv.TxHash) != 0 {
+			fields = append(fields, TxHashField(inv.TxHash))
+		}
+		if err != nil {
+			l.Warn("contract call failed", append(fields, ErrField(err))...)
+		} else {
+			l.Debug("contract call", fields...)
+		}
+		return err
+	}
+}
@@ -0,0 +1,144 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+type recordedSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *recordedSpan) SetAttributes(fields ...core.Field) {
+	for _, field := range fields {
+		s.attrs[field.Key] = field.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *recordedSpan) End() {
+	s.ended = true
+}
+
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, core.Span) {
+	span := &recordedSpan{name: name, attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestInterceptors(t *testing.T) {
+	_, c := newSimCore(t, false)
+	tracer := &recordingTracer{}
+	errDenied := errors.New("denied by policy")
+	var seen []string
+	deny := true
+	c.Interceptors = []core.Interceptor{
+		core.TracingInterceptor(tracer),
+		func(ctx context.Context, inv *core.Invocation, next core.Invoker) error {
+			seen = append(seen, inv.Kind.String()+" "+inv.Method)
+			if inv.Method == fs.FS_NODE_REGISTER && deny {
+				return errDenied
+			}
+			return next(ctx, inv)
+		},
+	}
+
+	_, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389")
+	if !errors.Is(err, errDenied) {
+		t.Fatalf("NodeRegister not denied: %v", err)
+	}
+	if _, err = c.GetNodeInfo(c.WalletAddr); err == nil {
+		t.Fatalf("denied NodeRegister was broadcast")
+	}
+
+	deny = false
+	tracer.spans = nil
+	txHash, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389")
+	if err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	if len(tracer.spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "ontfs "+fs.FS_NODE_REGISTER || !span.ended || span.err != nil ||
+		span.attrs["ontfs.signer"] != c.WalletAddr.ToBase58() ||
+		span.attrs["ontfs.tx_hash"] != core.TxHashField(txHash).Value {
+		t.Fatalf("unexpected span: %+v", span)
+	}
+	if len(seen) != 3 || seen[1] != "query "+fs.FS_NODE_QUERY {
+		t.Fatalf("unexpected invocations: %v", seen)
+	}
+}
+
+func TestInterceptors_ShortCircuit(t *testing.T) {
+	chain, c := newSimCore(t, false)
+	c.BatchGasPerFile = 10000
+	c.BatchParallelism = 1
+	errDenied := errors.New("denied by policy")
+	stores := 0
+	c.Interceptors = []core.Interceptor{func(ctx context.Context, inv *core.Invocation, next core.Invoker) error {
+		switch inv.Method {
+		case fs.FS_NODE_REGISTER:
+			return errDenied
+		case fs.FS_STORE_FILES:
+			// deny the second transaction of the batch
+			if stores++; stores == 2 {
+				return errDenied
+			}
+		}
+		return next(ctx, inv)
+	}}
+
+	pending, err := c.NodeRegisterAsync(context.Background(), 1024*1024, 1577836800+100000, 600,
+		"tcp://127.0.0.1:3389")
+	if !errors.Is(err, errDenied) || pending != nil {
+		t.Fatalf("NodeRegisterAsync not denied: %v, %v", pending, err)
+	}
+	if chain.Height() != 0 {
+		t.Fatalf("denied NodeRegisterAsync was broadcast")
+	}
+
+	var fileStores []common.FileStore
+	for i := 0; i < 5; i++ {
+		fileStores = append(fileStores, common.FileStore{
+			FileHash:       fmt.Sprintf("DeniedChunkFile%d", i),
+			FileBlockCount: 4,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			TimeExpired:    1577836800 + 3*3600,
+			StorageType:    fs.FileStorageTypeUseFile,
+		})
+	}
+	result, err := c.StoreFilesBatch(fileStores)
+	var batchErr *core.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Failed != 1 || !errors.Is(err, errDenied) {
+		t.Fatalf("StoreFilesBatch error: %v", err)
+	}
+	failedChunks := result.FailedChunks()
+	if len(failedChunks) != 1 || failedChunks[0].Start != 2 || failedChunks[0].End != 4 {
+		t.Fatalf("unexpected failed chunks: %+v", failedChunks)
+	}
+	if _, err = c.GetFileInfo("DeniedChunkFile2"); err == nil {
+		t.Fatalf("file of the denied transaction was stored")
+	}
+	if _, err = c.GetFileInfo("DeniedChunkFile4"); err != nil {
+		t.Fatalf("file of the last transaction not stored: %s", err.Error())
+	}
+}
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+// callContext runs fn and returns its error, or ctx.Err() if ctx is done
+// first. The backend calls cannot be interrupted, so fn keeps running in the
+// background after ctx is done and its result is dropped.
+func callContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ctx.Done() == nil {
+		return fn()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rpc is callContext for calls to the node: a failure that is not caused by
+// ctx is returned as an RPCError.
+func (c *Core) rpc(ctx context.Context, method string, fn func() error) error {
+	err := callContext(ctx, fn)
+	if err != nil && ctx.Err() == nil {
+		return &RPCError{Method: method, Err: err}
+	}
+	return err
+}
+
+// preExec pre-executes a read-only contract method and returns the payload of
+// a successful contract result, from the Cache of c when it holds it.
+func (c *Core) preExec(ctx context.Context, name string, method string, params []interface{}) ([]byte, error) {
+	inv := &Invocation{Kind: InvocationQuery, Method: method, Params: params}
+	cached := false
+	err := c.intercept(ctx, inv, func(ctx context.Context, inv *Invocation) error {
+		var generation uint64
+		if c.Cache != nil {
+			info, gen, ok := c.Cache.get(inv.Method, inv.Params)
+			if ok {
+				inv.Result, cached = info, true
+				return nil
+			}
+			generation = gen
+		}
+		var err error
+		inv.Result, err = c.preExecInfo(ctx, name, inv.Method, inv.Params)
+		if err == nil && c.Cache != nil {
+			c.Cache.put(generation, inv.Method, inv.Params, inv.Result)
+		}
+		return err
+	})
+	// cache hits go through the interceptors but are not pre-executions
+	if !cached {
+		c.observePreExec(method, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inv.Result, nil
+}
+
+func (c *Core) preExecInfo(ctx context.Context, name string, method string, params []interface{}) ([]byte, error) {
+	var ret *sdkcom.PreExecResult
+	err := c.read(ctx, method, func() error {
+		var err error
+		ret, err = c.Backend.PreExecInvokeNativeContract(c.contractAddress(), c.ContractVersion, method, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, err := ret.Result.ToByteArray()
+	if err != nil {
+		return nil, fmt.Errorf("%s result toByteArray: %s", name, err.Error())
+	}
+
+	retInfo := fs.DecRet(data)
+	if !retInfo.Ret {
+		return nil, &ContractRejectedError{Method: method, Message: string(retInfo.Info)}
+	}
+	return retInfo.Info, nil
+}
+
+// submit runs broadcast through the Interceptors of c.
+func (c *Core) submit(ctx context.Context, method string, params []interface{}) (ccom.Uint256, error) {
+	var txHash ccom.Uint256
+	signer := c.signer()
+	inv := c.transactionInvocation(method, params, signer)
+	err := c.intercept(ctx, inv, func(ctx context.Context, inv *Invocation) error {
+		var err error
+		txHash, err = c.broadcast(ctx, signer, inv.Method, inv.Params)
+		if err == nil {
+			inv.TxHash = txHash.ToArray()
+		}
+		return err
+	})
+	return txHash, err
+}
+
+// broadcast signs and broadcasts a contract invocation with signer, and the
+// payer of c when it is another account, after the transactions submitted
+// before it by the same signer. If ctx is done while broadcasting, the
+// transaction may still reach the node.
+func (c *Core) broadcast(ctx context.Context, signer Signer, method string,
+	params []interface{}) (ccom.Uint256, error) {
+	var txHash ccom.Uint256
+	if signer == nil {
+		return txHash, &NoSignerError{Method: method}
+	}
+	send := func(gasLimit uint64) error {
+		var err error
+		txHash, err = c.Backend.InvokeNativeContract(c.GasPrice, gasLimit, signer, c.ContractVersion,
+			c.contractAddress(), method, params)
+		return err
+	}
+	backend, built := c.Backend.(TxBackend)
+	if c.thirdPartyPayer(signer) {
+		if c.Payer == nil {
+			return txHash, &PayerSignatureError{Method: method, Payer: c.PayerAddr}
+		}
+		if !built {
+			return txHash, errors.New(method + " backend cannot send co-signed transactions")
+		}
+	} else {
+		// a broadcast is only retried by resending the same signed transaction
+		built = built && c.Retry.MaxAttempts > 1
+	}
+	if built {
+		send = func(gasLimit uint64) error {
+			tx, err := c.buildTransaction(backend, gasLimit, signer, method, params)
+			if err != nil {
+				return err
+			}
+			txHash, err = c.resend(ctx, backend, tx)
+			return err
+		}
+	}
+
+	gasLimit, err := c.gasLimit(ctx, signer, method, params)
+	if err != nil {
+		return txHash, err
+	}
+	err = c.enqueue(ctx, signer.Address(), method, func() error {
+		return send(gasLimit)
+	})
+	return txHash, err
+}
+
+// enqueue runs send, which broadcasts a transaction signed by account, after
+// the transactions submitted before it by the same account through c or its
+// views.
+func (c *Core) enqueue(ctx context.Context, account ccom.Address, method string, send func() error) error {
+	queues := c.queues
+	if queues == nil {
+		queues = defaultSubmitQueues
+	}
+	err := queues.run(ctx, account, send)
+	if err != nil && ctx.Err() == nil {
+		return &RPCError{Method: method, Err: err}
+	}
+	return err
+}
+
+// resend sends tx, and sends it again after a transient failure according to
+// the Retry policy of c. A failure may come after the node accepted tx: a
+// resent tx the node reports as a duplicate was sent.
+func (c *Core) resend(ctx context.Context, backend TxBackend, tx *types.MutableTransaction) (ccom.Uint256, error) {
+	var txHash ccom.Uint256
+	attempt := 0
+	err := c.Retry.do(ctx, func() error {
+		attempt++
+		var err error
+		txHash, err = backend.SendTransaction(tx)
+		if err != nil && attempt > 1 && isDuplicateTxError(err) {
+			txHash, err = tx.Hash(), nil
+		}
+		return err
+	})
+	return txHash, err
+}
+
+// contractAddress returns ContractAddr, or the native contract when it is not
+// set.
+func (c *Core) contractAddress() ccom.Address {
+	if c.ContractAddr == ccom.ADDRESS_EMPTY {
+		return utils.OntFSContractAddress
+	}
+	return c.ContractAddr
+}
+
+func hexTxHash(txHash []byte) string {
+	hash, err := ccom.Uint256ParseFromBytes(txHash)
+	if err != nil {
+		return ""
+	}
+	return hash.ToHexString()
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	ccom "github.com/ontio/ontology/common"
+)
+
+// Field is a key and value attached to a log entry.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// MethodField is the contract method a log entry is about.
+func MethodField(method string) Field {
+	return Field{Key: "method", Value: method}
+}
+
+func TxHashField(txHash []byte) Field {
+	return Field{Key: "tx_hash", Value: hexTxHash(txHash)}
+}
+
+func FileHashField(fileHash string) Field {
+	return Field{Key: "file_hash", Value: fileHash}
+}
+
+// NodeAddrField is the wallet address of a storage node.
+func NodeAddrField(addr ccom.Address) Field {
+	return Field{Key: "node_addr", Value: addr.ToBase58()}
+}
+
+func ErrField(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// Logger receives structured log entries. NewSlogLogger and NewPrintfLogger
+// adapt the standard library loggers to it.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...Field) {}
+func (nopLogger) Info(string, ...Field)  {}
+func (nopLogger) Warn(string, ...Field)  {}
+func (nopLogger) Error(string, ...Field) {}
+
+// NopLogger returns a Logger discarding every entry.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger writing to logger, slog.Default() when nil.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return slogLogger{logger: logger}
+}
+
+func (l slogLogger) Debug(msg string, fields ...Field) {
+	l.logger.Debug(msg, slogArgs(fields)...)
+}
+
+func (l slogLogger) Info(msg string, fields ...Field) {
+	l.logger.Info(msg, slogArgs(fields)...)
+}
+
+func (l slogLogger) Warn(msg string, fields ...Field) {
+	l.logger.Warn(msg, slogArgs(fields)...)
+}
+
+func (l slogLogger) Error(msg string, fields ...Field) {
+	l.logger.Error(msg, slogArgs(fields)...)
+}
+
+func slogArgs(fields []Field) []interface{} {
+	args := make([]interface{}, len(fields))
+	for i, field := range fields {
+		args[i] = slog.Any(field.Key, field.Value)
+	}
+	return args
+}
+
+// Printer is implemented by *log.Logger.
+type Printer interface {
+	Printf(format string, v ...interface{})
+}
+
+type printfLogger struct {
+	printer Printer
+	debug   bool
+}
+
+// NewPrintfLogger returns a Logger printing one line per entry to printer,
+// such as a *log.Logger, in the form `LEVEL msg key=value ...`. Debug
+// entries are dropped unless debug is set.
+func NewPrintfLogger(printer Printer, debug bool) Logger {
+	return printfLogger{printer: printer, debug: debug}
+}
+
+func (l printfLogger) Debug(msg string, fields ...Field) {
+	if l.debug {
+		l.print("DEBUG", msg, fields)
+	}
+}
+
+func (l printfLogger) Info(msg string, fields ...Field) {
+	l.print("INFO", msg, fields)
+}
+
+func (l printfLogger) Warn(msg string, fields ...Field) {
+	l.print("WARN", msg, fields)
+}
+
+func (l printfLogger) Error(msg string, fields ...Field) {
+	l.print("ERROR", msg, fields)
+}
+
+func (l printfLogger) print(level string, msg string, fields []Field) {
+	var line strings.Builder
+	line.WriteString(level)
+	line.WriteByte(' ')
+	line.WriteString(msg)
+	for _, field := range fields {
+		value := fmt.Sprint(field.Value)
+		if strings.ContainsAny(value, " \"=\n") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&line, " %s=%s", field.Key, value)
+	}
+	l.printer.Printf("%s", line.String())
+}
+
+type loggerHolder struct {
+	logger Logger
+}
+
+var defaultLogger atomic.Value
+
+func init() {
+	defaultLogger.Store(loggerHolder{logger: NopLogger()})
+}
+
+// SetDefaultLogger sets the Logger of Init and of the Cores whose Logger is
+// nil. It is NopLogger until set.
+func SetDefaultLogger(logger Logger) {
+	if logger == nil {
+		logger = NopLogger()
+	}
+	defaultLogger.Store(loggerHolder{logger: logger})
+}
+
+// DefaultLogger returns the Logger set by SetDefaultLogger.
+func DefaultLogger() Logger {
+	return defaultLogger.Load().(loggerHolder).logger
+}
+
+// logger returns Logger, or the default Logger when it is not set.
+func (c *Core) logger() Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return DefaultLogger()
+}
@@ -0,0 +1,77 @@
+package core_test
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/core"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	_, c := newSimCore(t, false)
+	c.Logger = core.NewPrintfLogger(log.New(&buf, "", 0), true)
+
+	txHash, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389")
+	if err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	for _, line := range []string{
+		"DEBUG transaction submitted method=ofsNodeRegister tx_hash=",
+		"DEBUG transaction confirmed method=ofsNodeRegister tx_hash=",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Fatalf("missing %q in:\n%s", line, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), core.TxHashField(txHash).Value.(string)) {
+		t.Fatalf("tx hash not logged:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	logger := core.NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	logger.Warn("file rejected", core.FileHashField("QmFile"), core.F("reason", "no space"))
+	if line := buf.String(); !strings.Contains(line, `msg="file rejected" file_hash=QmFile reason="no space"`) {
+		t.Fatalf("unexpected slog line: %s", line)
+	}
+}
+
+func TestLogger_Failures(t *testing.T) {
+	var buf bytes.Buffer
+	_, c := newSimCore(t, false)
+	c.Logger = core.NewPrintfLogger(log.New(&buf, "", 0), false)
+	if _, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	buf.Reset()
+	if _, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err == nil {
+		t.Fatalf("second NodeRegister succeeded")
+	}
+	if line := buf.String(); strings.Contains(line, "DEBUG") ||
+		!strings.HasPrefix(line, "WARN transaction failed method=ofsNodeRegister tx_hash=") ||
+		!strings.Contains(line, `error="ofsNodeRegister rejected by contract`) {
+		t.Fatalf("unexpected failure log:\n%s", line)
+	}
+
+	// a logger at error level only gets the failures of Init
+	buf.Reset()
+	defer core.SetDefaultLogger(core.DefaultLogger())
+	core.SetDefaultLogger(core.NewSlogLogger(slog.New(slog.NewTextHandler(&buf,
+		&slog.HandlerOptions{Level: slog.LevelError}))))
+	c.Logger = nil
+	if _, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err == nil {
+		t.Fatalf("third NodeRegister succeeded")
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("warning logged at error level: %s", buf.String())
+	}
+	if core.Init("./no-such-wallet.dat", "pwd", "http://127.0.0.1:20336", 0, 20000) != nil {
+		t.Fatalf("Init of a missing wallet succeeded")
+	}
+	if line := buf.String(); !strings.Contains(line, `level=ERROR msg="init error" error=`) {
+		t.Fatalf("unexpected init failure log: %s", line)
+	}
+}
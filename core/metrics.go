@@ -0,0 +1,37 @@
+package core
+
+import (
+	"time"
+)
+
+// Metrics receives the outcome of the calls a Core makes to the contract.
+// The metrics package implements it with Prometheus collectors.
+type Metrics interface {
+	// ObservePreExec is called after a query or a gas estimation.
+	ObservePreExec(method string, err error)
+	// ObserveSubmit is called after a transaction was broadcast, or failed
+	// to be.
+	ObserveSubmit(method string, err error)
+	// ObserveConfirm is called once the tracking of a transaction ends,
+	// elapsed after its submission. receipt is nil when err is set, unless
+	// the transaction was confirmed but its batch result could not be read.
+	ObserveConfirm(method string, elapsed time.Duration, receipt *TxReceipt, err error)
+}
+
+func (c *Core) observePreExec(method string, err error) {
+	if c.Metrics != nil {
+		c.Metrics.ObservePreExec(method, err)
+	}
+}
+
+func (c *Core) observeSubmit(method string, err error) {
+	if c.Metrics != nil {
+		c.Metrics.ObserveSubmit(method, err)
+	}
+}
+
+func (c *Core) observeConfirm(pending *PendingTx) {
+	if c.Metrics != nil {
+		c.Metrics.ObserveConfirm(pending.method, time.Since(pending.submitted), pending.receipt, pending.err)
+	}
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+// Network bundles the settings of one Ontology network: the RPC endpoints of
+// its nodes, the ontfs contract deployment and the default gas settings.
+type Network struct {
+	Name            string
+	RPCAddrs        []string
+	ContractAddr    ccom.Address
+	ContractVersion byte
+	GasPrice        uint64
+	GasLimit        uint64
+}
+
+// MainNet returns the Ontology main network.
+func MainNet() Network {
+	return Network{
+		Name:         "mainnet",
+		RPCAddrs:     []string{"http://dappnode1.ont.io:20336", "http://dappnode2.ont.io:20336"},
+		ContractAddr: utils.OntFSContractAddress,
+		GasPrice:     2500,
+		GasLimit:     20000,
+	}
+}
+
+// TestNet returns the Polaris test network.
+func TestNet() Network {
+	return Network{
+		Name:         "testnet",
+		RPCAddrs:     []string{"http://polaris1.ont.io:20336", "http://polaris2.ont.io:20336"},
+		ContractAddr: utils.OntFSContractAddress,
+		GasPrice:     2500,
+		GasLimit:     20000,
+	}
+}
+
+// LocalNet returns a single node devnet, such as one started with
+// "ontology --testmode", which accepts a zero gas price.
+func LocalNet() Network {
+	return Network{
+		Name:         "local",
+		RPCAddrs:     []string{"http://127.0.0.1:20336"},
+		ContractAddr: utils.OntFSContractAddress,
+		GasPrice:     0,
+		GasLimit:     20000,
+	}
+}
+
+// NetworkByName returns the predefined network called name.
+func NetworkByName(name string) (Network, bool) {
+	for _, network := range []Network{MainNet(), TestNet(), LocalNet()} {
+		if network.Name == name {
+			return network, true
+		}
+	}
+	return Network{}, false
+}
+
+// WithNetwork applies the endpoints, contract and gas settings of network.
+// The options that follow it override them.
+func WithNetwork(network Network) Option {
+	return func(o *options) {
+		o.rpcAddrs = append([]string(nil), network.RPCAddrs...)
+		o.contractAddr = network.ContractAddr
+		o.contractVersion = network.ContractVersion
+		o.gasPrice = network.GasPrice
+		o.gasLimit = network.GasLimit
+	}
+}
@@ -0,0 +1,246 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// NodeEndpoint is the network address a storage node registered, such as
+// "tcp://10.0.1.66:3389".
+type NodeEndpoint struct {
+	Scheme string
+	Host   string
+	Port   uint16
+}
+
+// ParseNodeEndpoint parses the NodeNetAddr of a node. An address without a
+// scheme is a tcp one.
+func ParseNodeEndpoint(netAddr string) (*NodeEndpoint, error) {
+	addr := netAddr
+	if !strings.Contains(addr, "://") {
+		addr = "tcp://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("node endpoint %q: %s", netAddr, err.Error())
+	}
+	host, portStr, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("node endpoint %q: %s", netAddr, err.Error())
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || len(host) == 0 {
+		return nil, fmt.Errorf("node endpoint %q: invalid host or port", netAddr)
+	}
+	return &NodeEndpoint{Scheme: u.Scheme, Host: host, Port: uint16(port)}, nil
+}
+
+// Address returns the host:port of e, as used by net.Dial.
+func (e *NodeEndpoint) Address() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(int(e.Port)))
+}
+
+func (e *NodeEndpoint) String() string {
+	return e.Scheme + "://" + e.Address()
+}
+
+// Node is a storage node of a NodeDirectory. Endpoint is nil when its
+// NodeNetAddr cannot be parsed.
+type Node struct {
+	fs.FsNodeInfo
+	Endpoint *NodeEndpoint
+}
+
+// NodeFilter selects the nodes of a NodeDirectory. Zero fields do not
+// filter.
+type NodeFilter struct {
+	MinRestVol uint64
+	// MinServiceTime selects the nodes serving until at least that time.
+	MinServiceTime uint64
+	// MaxMinPdpInterval selects the nodes accepting files proved at that
+	// interval, whose MinPdpInterval is not longer.
+	MaxMinPdpInterval uint64
+	MinPledge         uint64
+	// WithEndpoint selects the nodes whose NodeNetAddr can be parsed.
+	WithEndpoint bool
+}
+
+func (f *NodeFilter) match(node *Node) bool {
+	switch {
+	case node.RestVol < f.MinRestVol,
+		node.ServiceTime < f.MinServiceTime,
+		f.MaxMinPdpInterval != 0 && node.MinPdpInterval > f.MaxMinPdpInterval,
+		node.Pledge < f.MinPledge,
+		f.WithEndpoint && node.Endpoint == nil:
+		return false
+	}
+	return true
+}
+
+// NodeSortField is the field a NodeDirectory is sorted on.
+type NodeSortField int
+
+const (
+	// NodeSortNone keeps the order of the contract.
+	NodeSortNone NodeSortField = iota
+	NodeSortRestVol
+	NodeSortServiceTime
+	NodeSortMinPdpInterval
+	NodeSortPledge
+)
+
+func (f NodeSortField) key(node *Node) uint64 {
+	switch f {
+	case NodeSortRestVol:
+		return node.RestVol
+	case NodeSortServiceTime:
+		return node.ServiceTime
+	case NodeSortMinPdpInterval:
+		return node.MinPdpInterval
+	case NodeSortPledge:
+		return node.Pledge
+	}
+	return 0
+}
+
+// NodeSort orders a NodeDirectory on Field, ascending unless Descending is
+// set. Nodes with the same value keep the order of the contract.
+type NodeSort struct {
+	Field      NodeSortField
+	Descending bool
+}
+
+// nodeFetchMin is the number of nodes a NodeDirectory reads first.
+const nodeFetchMin = 64
+
+// nodeInfoList reads the whole node list, doubling the count asked for until
+// the contract returns fewer nodes.
+func (c *Core) nodeInfoList(ctx context.Context) ([]fs.FsNodeInfo, error) {
+	for count := uint64(nodeFetchMin); ; count *= 2 {
+		nodeList, err := c.GetNodeInfoListContext(ctx, count)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(nodeList.NodesInfo)) < count {
+			return nodeList.NodesInfo, nil
+		}
+	}
+}
+
+// NodeDirectory is a filtered and sorted view of the registered storage
+// nodes, read page by page. The nodes read are kept by the directory, so
+// turning pages does not read them again.
+//
+// The contract has no paging: it only returns the first nodes of its list,
+// up to a count. A directory reads the list doubling the count at each read,
+// as far as the pages asked for need when it is unsorted, and to its end
+// when it is created otherwise. A node registering or leaving while the pages
+// of an unsorted directory are read may shift them.
+type NodeDirectory struct {
+	c      *Core
+	filter NodeFilter
+	nodes  []Node
+	// fetched is the length of the node list read so far, and complete
+	// tells that it is the whole list.
+	fetched  uint64
+	complete bool
+}
+
+// NodeDirectory returns the registered nodes matching filter, sorted by
+// order.
+func (c *Core) NodeDirectory(ctx context.Context, filter NodeFilter, order NodeSort) (*NodeDirectory, error) {
+	d := &NodeDirectory{c: c, filter: filter}
+	if order.Field == NodeSortNone {
+		return d, nil
+	}
+	if err := d.load(ctx, -1); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(d.nodes, func(i, j int) bool {
+		a, b := order.Field.key(&d.nodes[i]), order.Field.key(&d.nodes[j])
+		if order.Descending {
+			return a > b
+		}
+		return a < b
+	})
+	return d, nil
+}
+
+// load reads the node list until d holds want nodes, or all of them when
+// want is negative.
+func (d *NodeDirectory) load(ctx context.Context, want int) error {
+	for !d.complete && (want < 0 || len(d.nodes) < want) {
+		count := 2 * d.fetched
+		if count < nodeFetchMin {
+			count = nodeFetchMin
+		}
+		nodeList, err := d.c.GetNodeInfoListContext(ctx, count)
+		if err != nil {
+			return err
+		}
+		infos := nodeList.NodesInfo
+		d.complete = uint64(len(infos)) < count
+		if uint64(len(infos)) <= d.fetched {
+			// the list did not grow past the nodes already read
+			continue
+		}
+		for _, info := range infos[d.fetched:] {
+			node := Node{FsNodeInfo: info}
+			node.Endpoint, _ = ParseNodeEndpoint(string(info.NodeNetAddr))
+			if d.filter.match(&node) {
+				d.nodes = append(d.nodes, node)
+			}
+		}
+		d.fetched = uint64(len(infos))
+	}
+	return nil
+}
+
+// Len returns the number of nodes in d, reading the whole node list.
+func (d *NodeDirectory) Len(ctx context.Context) (int, error) {
+	if err := d.load(ctx, -1); err != nil {
+		return 0, err
+	}
+	return len(d.nodes), nil
+}
+
+// Pages returns the number of pages of size nodes, reading the whole node
+// list.
+func (d *NodeDirectory) Pages(ctx context.Context, size int) (int, error) {
+	if size <= 0 {
+		return 0, nil
+	}
+	count, err := d.Len(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return (count + size - 1) / size, nil
+}
+
+// Page returns a copy of the page-th page, from 0, of size nodes. It is empty
+// past the last page.
+func (d *NodeDirectory) Page(ctx context.Context, page int, size int) ([]Node, error) {
+	if page < 0 || size <= 0 {
+		return nil, nil
+	}
+	end := (page + 1) * size
+	if err := d.load(ctx, end); err != nil {
+		return nil, err
+	}
+	if page*size >= len(d.nodes) {
+		return nil, nil
+	}
+	if end > len(d.nodes) {
+		end = len(d.nodes)
+	}
+	nodes := make([]Node, end-page*size)
+	copy(nodes, d.nodes[page*size:end])
+	return nodes, nil
+}
@@ -0,0 +1,112 @@
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/core"
+	ont "github.com/ontio/ontology-go-sdk"
+)
+
+func TestNodeDirectory(t *testing.T) {
+	chain, c := newSimCore(t, false)
+	for i, netAddr := range []string{"tcp://10.0.0.1:3389", "10.0.0.2:3389", "not an address"} {
+		node := core.InitWithBackend(chain, ont.NewAccount(), 0, 20000)
+		volume := uint64(i+1) * 1024 * 1024
+		if _, err := node.NodeRegister(volume, 1577836800+100000, 600, netAddr); err != nil {
+			t.Fatalf("NodeRegister error: %s", err.Error())
+		}
+	}
+
+	directory, err := c.NodeDirectory(context.Background(), core.NodeFilter{MinRestVol: 2 * 1024 * 1024},
+		core.NodeSort{Field: core.NodeSortRestVol, Descending: true})
+	if err != nil {
+		t.Fatalf("NodeDirectory error: %s", err.Error())
+	}
+	pages, err := directory.Pages(context.Background(), 1)
+	if err != nil || pages != 2 {
+		t.Fatalf("expected 2 pages, got %d: %v", pages, err)
+	}
+	first, err := directory.Page(context.Background(), 0, 1)
+	if err != nil {
+		t.Fatalf("Page error: %s", err.Error())
+	}
+	second, err := directory.Page(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("Page error: %s", err.Error())
+	}
+	if len(first) != 1 || len(second) != 1 || first[0].RestVol < second[0].RestVol {
+		t.Fatalf("nodes not sorted by RestVol: %+v %+v", first, second)
+	}
+	if first[0].Endpoint != nil || second[0].Endpoint.String() != "tcp://10.0.0.2:3389" {
+		t.Fatalf("unexpected endpoints: %v %v", first[0].Endpoint, second[0].Endpoint)
+	}
+	if past, _ := directory.Page(context.Background(), 2, 1); len(past) != 0 {
+		t.Fatalf("page past the end is not empty")
+	}
+
+	// an unsorted directory reads the node list as its pages need
+	directory, err = c.NodeDirectory(context.Background(), core.NodeFilter{WithEndpoint: true}, core.NodeSort{})
+	if err != nil {
+		t.Fatalf("NodeDirectory error: %s", err.Error())
+	}
+	page, err := directory.Page(context.Background(), 0, 1)
+	if err != nil || len(page) != 1 || page[0].Endpoint == nil {
+		t.Fatalf("unexpected first page: %+v %v", page, err)
+	}
+	if count, err := directory.Len(context.Background()); err != nil || count != 2 {
+		t.Fatalf("expected 2 nodes with an endpoint, got %d: %v", count, err)
+	}
+}
+
+func TestNodeDirectory_AllNodes(t *testing.T) {
+	chain, c := newSimCore(t, false)
+	// more nodes than the first read of a directory returns
+	const count = 70
+	pendings := make([]*core.PendingTx, 0, count)
+	for i := 0; i < count; i++ {
+		node := core.InitWithBackend(chain, ont.NewAccount(), 0, 20000)
+		pending, err := node.NodeRegisterAsync(context.Background(), uint64(i+1)*1024*1024, 1577836800+100000,
+			600, fmt.Sprintf("tcp://10.0.1.%d:3389", i))
+		if err != nil {
+			t.Fatalf("NodeRegisterAsync error: %s", err.Error())
+		}
+		pendings = append(pendings, pending)
+	}
+	if err := core.WaitAll(context.Background(), pendings...); err != nil {
+		t.Fatalf("WaitAll error: %s", err.Error())
+	}
+
+	// an empty filter keeps every node
+	directory, err := c.NodeDirectory(context.Background(), core.NodeFilter{},
+		core.NodeSort{Field: core.NodeSortRestVol})
+	if err != nil {
+		t.Fatalf("NodeDirectory error: %s", err.Error())
+	}
+	if n, err := directory.Len(context.Background()); err != nil || n != count {
+		t.Fatalf("expected %d nodes, got %d: %v", count, n, err)
+	}
+	last, err := directory.Page(context.Background(), 6, 10)
+	if err != nil || len(last) != 10 || last[9].RestVol != count*1024*1024 {
+		t.Fatalf("unexpected last page: %d nodes, %v", len(last), err)
+	}
+
+	// the pages belong to the caller
+	last[0], last[9].RestVol = core.Node{}, 0
+	again, err := directory.Page(context.Background(), 6, 10)
+	if err != nil || len(again) != 10 || again[0].RestVol == 0 || again[9].RestVol != count*1024*1024 {
+		t.Fatalf("page changed by its previous caller: %+v, %v", again, err)
+	}
+
+	unsorted, err := c.NodeDirectory(context.Background(), core.NodeFilter{}, core.NodeSort{})
+	if err != nil {
+		t.Fatalf("NodeDirectory error: %s", err.Error())
+	}
+	if page, err := unsorted.Page(context.Background(), 7, 10); err != nil || len(page) != 0 {
+		t.Fatalf("unexpected page past the end: %d nodes, %v", len(page), err)
+	}
+	if page, err := unsorted.Page(context.Background(), 6, 10); err != nil || len(page) != 10 {
+		t.Fatalf("unexpected last page: %d nodes, %v", len(page), err)
+	}
+}
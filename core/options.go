@@ -0,0 +1,314 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	ont "github.com/ontio/ontology-go-sdk"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const defaultGasPrice = uint64(2500)
+
+// Option configures a Core created by New.
+type Option func(o *options)
+
+type options struct {
+	walletPath      string
+	password        func() ([]byte, error)
+	rpcAddrs        []string
+	endpointPool    EndpointPoolConfig
+	backend         ChainBackend
+	gasPrice        uint64
+	gasLimit        uint64
+	confirmTimeout  time.Duration
+	contractAddr    ccom.Address
+	contractVersion byte
+	logger          Logger
+	retry           RetryPolicy
+	metrics         Metrics
+	interceptors    []Interceptor
+	cache           *QueryCache
+	placementCheck  bool
+	gasEstimation   bool
+	wsAddr          string
+}
+
+// WithWallet makes the Core sign with the default account of the wallet file
+// at path. The wallet is unlocked with the password given by WithPassword or
+// WithPasswordFunc.
+func WithWallet(path string) Option {
+	return func(o *options) {
+		o.walletPath = path
+	}
+}
+
+func WithPassword(password []byte) Option {
+	return func(o *options) {
+		o.password = func() ([]byte, error) {
+			return password, nil
+		}
+	}
+}
+
+// WithPasswordFunc sets the source of the wallet password, such as a
+// terminal prompt or a secret store. New calls it once, if a wallet is set.
+func WithPasswordFunc(password func() ([]byte, error)) Option {
+	return func(o *options) {
+		o.password = password
+	}
+}
+
+// WithRPCAddress sets the address of the Ontology node the default backend
+// talks to, such as "http://127.0.0.1:20336".
+func WithRPCAddress(addr string) Option {
+	return func(o *options) {
+		o.rpcAddrs = []string{addr}
+	}
+}
+
+// WithRPCAddresses makes the default backend an EndpointPool over the nodes
+// at addrs, the first one being the primary.
+func WithRPCAddresses(addrs ...string) Option {
+	return func(o *options) {
+		o.rpcAddrs = addrs
+	}
+}
+
+// WithEndpointPool configures the health checks of the EndpointPool built
+// for several RPC addresses.
+func WithEndpointPool(cfg EndpointPoolConfig) Option {
+	return func(o *options) {
+		o.endpointPool = cfg
+	}
+}
+
+// WithBackend replaces the default backend, built from the RPC address.
+func WithBackend(backend ChainBackend) Option {
+	return func(o *options) {
+		o.backend = backend
+	}
+}
+
+func WithGasPrice(gasPrice uint64) Option {
+	return func(o *options) {
+		o.gasPrice = gasPrice
+	}
+}
+
+func WithGasLimit(gasLimit uint64) Option {
+	return func(o *options) {
+		o.gasLimit = gasLimit
+	}
+}
+
+// WithConfirmTimeout sets how long a submitted transaction is awaited.
+func WithConfirmTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.confirmTimeout = timeout
+	}
+}
+
+// WithContractAddress targets an ontfs contract deployed at addr instead of
+// the native one.
+func WithContractAddress(addr ccom.Address) Option {
+	return func(o *options) {
+		o.contractAddr = addr
+	}
+}
+
+func WithContractVersion(version byte) Option {
+	return func(o *options) {
+		o.contractVersion = version
+	}
+}
+
+// WithLogger routes the log of the Core, its ConfirmTracker and the
+// EndpointPool built for several RPC addresses to logger instead of the
+// default Logger.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+// WithInterceptors appends interceptors to the chain wrapping every call to
+// the contract.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// WithQueryCache caches the results of queries in cache, which may be shared
+// by several Cores.
+func WithQueryCache(cache *QueryCache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// WithPlacementCheck makes StoreFiles fail with a PlacementError when the
+// registered nodes cannot hold the copies of a file.
+func WithPlacementCheck() Option {
+	return func(o *options) {
+		o.placementCheck = true
+	}
+}
+
+// WithGasEstimation makes the Core pre-execute every transaction to submit it
+// with the gas it needs as its limit, in place of GasLimit.
+func WithGasEstimation() Option {
+	return func(o *options) {
+		o.gasEstimation = true
+	}
+}
+
+// WithConfirmTracker makes the Core wait for confirmations through a
+// ConfirmTracker subscribed to the websocket api at wsAddr, started by New.
+func WithConfirmTracker(wsAddr string) Option {
+	return func(o *options) {
+		o.wsAddr = wsAddr
+	}
+}
+
+// New creates a Core configured by opts. Without WithWallet the Core can only
+// query the contract. A missing wallet file is reported with an error
+// matching os.ErrNotExist, and a wallet account that cannot be unlocked with
+// an error matching ErrWalletPassword.
+func New(opts ...Option) (*Core, error) {
+	o := options{
+		gasPrice:     defaultGasPrice,
+		gasLimit:     minGasLimit,
+		contractAddr: utils.OntFSContractAddress,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &Core{
+		WalletPath:      o.walletPath,
+		GasPrice:        o.gasPrice,
+		GasLimit:        o.gasLimit,
+		Backend:         o.backend,
+		ConfirmTimeout:  o.confirmTimeout,
+		ContractAddr:    o.contractAddr,
+		ContractVersion: o.contractVersion,
+		Logger:          o.logger,
+		Retry:           o.retry,
+		Metrics:         o.metrics,
+		Interceptors:    o.interceptors,
+		Cache:           o.cache,
+		PlacementCheck:  o.placementCheck,
+		GasEstimation:   o.gasEstimation,
+		queues:          newSubmitQueues(),
+	}
+	if len(o.rpcAddrs) != 0 {
+		c.OntRpcSrvAddr = o.rpcAddrs[0]
+	}
+	c.OntSdk = ont.NewOntologySdk()
+	c.OntSdk.NewRpcClient().SetAddress(c.OntRpcSrvAddr)
+	if len(o.walletPath) != 0 {
+		if err := c.openWallet(o.password); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Backend == nil && len(o.rpcAddrs) > 1 {
+		endpoints := make([]Endpoint, len(o.rpcAddrs))
+		for i, addr := range o.rpcAddrs {
+			ontSdk := ont.NewOntologySdk()
+			ontSdk.NewRpcClient().SetAddress(addr)
+			endpoints[i] = Endpoint{Addr: addr, Backend: NewSdkBackend(ontSdk)}
+		}
+		pool, err := NewEndpointPool(o.endpointPool, endpoints...)
+		if err != nil {
+			return nil, err
+		}
+		pool.Logger = o.logger
+		pool.Start()
+		c.Backend = pool
+	}
+	if c.Backend == nil {
+		c.Backend = NewSdkBackend(c.OntSdk)
+	}
+	if len(o.wsAddr) != 0 {
+		c.StartConfirmTracker(o.wsAddr)
+	}
+	return c, nil
+}
+
+// openWallet opens the wallet at WalletPath and unlocks its default account
+// with the password from password.
+func (c *Core) openWallet(password func() ([]byte, error)) error {
+	if password == nil {
+		return fmt.Errorf("no password for wallet %s", c.WalletPath)
+	}
+	var err error
+	c.Password, err = password()
+	if err != nil {
+		return fmt.Errorf("wallet %s password: %w", c.WalletPath, err)
+	}
+	if _, err = os.Stat(c.WalletPath); err != nil {
+		return fmt.Errorf("open wallet: %w", err)
+	}
+	c.Wallet, err = c.OntSdk.OpenWallet(c.WalletPath)
+	if err != nil {
+		return fmt.Errorf("open wallet %s: %s", c.WalletPath, err.Error())
+	}
+	c.DefAcc, err = defaultAccount(c.Wallet, c.Password)
+	if err != nil {
+		return fmt.Errorf("wallet %s: %w", c.WalletPath, err)
+	}
+	c.WalletAddr = c.DefAcc.Address
+	c.accounts = newAccountCache()
+	c.accounts.add(c.DefAcc, "")
+	return nil
+}
+
+// Close stops the ConfirmTracker of c and the health checks of the
+// EndpointPool New built for several RPC addresses. The views of c share
+// them, so they are closed too.
+func (c *Core) Close() {
+	if c.Tracker != nil {
+		c.Tracker.Close()
+	}
+	if pool, ok := c.Backend.(*EndpointPool); ok {
+		pool.Close()
+	}
+}
+
+// defaultAccount unlocks the default account of wallet. Any failure other
+// than a missing default account is a wrong password.
+func defaultAccount(wallet *ont.Wallet, password []byte) (*ont.Account, error) {
+	found := false
+	for i := 1; i <= wallet.GetAccountCount(); i++ {
+		if meta := wallet.GetAccountMetadataByIndex(i); meta != nil && meta.IsDefault {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.New("no default account")
+	}
+	acc, err := wallet.GetDefaultAccount(password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWalletPassword, err.Error())
+	}
+	return acc, nil
+}
@@ -0,0 +1,221 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+// ExportedTx is a transaction built and signed by the signer of a Core that
+// still needs the signature of its payer. Raw is the serialized transaction,
+// which CoSignTransaction signs for the payer.
+type ExportedTx struct {
+	Method string
+	TxHash []byte
+	// Signer is the account that signed the transaction, and Payer the one
+	// paying for it.
+	Signer ccom.Address
+	Payer  ccom.Address
+	Raw    []byte
+}
+
+// WithPayer returns a view of c whose transactions are co-signed by payer,
+// which pays their gas and the fees of UpdateSpace and RenewFiles. The
+// Backend must be a TxBackend.
+func (c *Core) WithPayer(payer Signer) *Core {
+	view := *c
+	view.Payer = payer
+	view.PayerAddr = payer.Address()
+	return &view
+}
+
+// WithPayerAddress returns a view of c paid by the account at payer, whose
+// key is held elsewhere. The transactions of the view cannot be submitted
+// directly: they are exported with Export, co-signed with
+// CoSignTransaction and broadcast with SubmitTransaction.
+func (c *Core) WithPayerAddress(payer ccom.Address) *Core {
+	view := *c
+	view.Payer = nil
+	view.PayerAddr = payer
+	return &view
+}
+
+// payerAddress returns the account that pays the fees of the invocations of c.
+func (c *Core) payerAddress() ccom.Address {
+	if c.Payer != nil {
+		return c.Payer.Address()
+	}
+	if c.PayerAddr != ccom.ADDRESS_EMPTY {
+		return c.PayerAddr
+	}
+	return c.accountAddress()
+}
+
+// thirdPartyPayer reports whether the transactions signed by signer are paid
+// by another account.
+func (c *Core) thirdPartyPayer(signer Signer) bool {
+	if c.Payer == nil && c.PayerAddr == ccom.ADDRESS_EMPTY {
+		return false
+	}
+	return signer == nil || c.payerAddress() != signer.Address()
+}
+
+// canPreExec reports whether the Backend can pre-execute the invocations of c
+// signed by signer. A transaction paid by a third party needs the signature
+// of the payer to be pre-executed.
+func (c *Core) canPreExec(signer Signer) bool {
+	if c.thirdPartyPayer(signer) {
+		_, ok := c.Backend.(TxBackend)
+		return ok && c.Payer != nil
+	}
+	_, ok := c.Backend.(EstimateBackend)
+	return ok
+}
+
+// buildTransaction builds the transaction of an invocation paid by the payer
+// of c, signed by signer and, when c holds its key, by the payer.
+func (c *Core) buildTransaction(backend TxBackend, gasLimit uint64, signer Signer, method string,
+	params []interface{}) (*types.MutableTransaction, error) {
+	tx, err := backend.NewNativeInvokeTransaction(c.GasPrice, gasLimit, c.ContractVersion,
+		c.contractAddress(), method, params)
+	if err != nil {
+		return nil, err
+	}
+	tx.Payer = c.payerAddress()
+	if err = signer.SignTransaction(tx); err != nil {
+		return nil, err
+	}
+	if c.Payer != nil && c.thirdPartyPayer(signer) {
+		if err = c.Payer.SignTransaction(tx); err != nil {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// Export builds the transaction of a contract invocation, signed by the
+// signer of c, without broadcasting it. The transaction is ready to be
+// co-signed by its payer:
+//
+//	customer := c.WithPayerAddress(billingAddr)
+//	tx, err := customer.Export(ctx, fs.FS_RENEW_FILES, []interface{}{param})
+//
+// The gas limit of a transaction whose payer key is held elsewhere is
+// GasLimit, as it cannot be pre-executed without the payer signature.
+func (c *Core) Export(ctx context.Context, method string, params []interface{}) (*ExportedTx, error) {
+	backend, ok := c.Backend.(TxBackend)
+	if !ok {
+		return nil, errors.New(method + " backend cannot build transactions")
+	}
+	signer := c.signer()
+	if signer == nil {
+		return nil, &NoSignerError{Method: method}
+	}
+	gasLimit, err := c.gasLimit(ctx, signer, method, params)
+	if err != nil {
+		return nil, err
+	}
+	var tx *types.MutableTransaction
+	err = c.rpc(ctx, method, func() error {
+		var err error
+		tx, err = c.buildTransaction(backend, gasLimit, signer, method, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	raw, err := encodeTransaction(tx)
+	if err != nil {
+		return nil, fmt.Errorf("%s encode transaction: %s", method, err.Error())
+	}
+	return &ExportedTx{
+		Method: method,
+		TxHash: tx.Hash().ToArray(),
+		Signer: signer.Address(),
+		Payer:  tx.Payer,
+		Raw:    raw,
+	}, nil
+}
+
+// CoSignTransaction adds the signature of payer to raw, a transaction exported
+// by Export, and returns the serialized result.
+func CoSignTransaction(raw []byte, payer Signer) ([]byte, error) {
+	tx, err := decodeTransaction(raw)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Payer != payer.Address() {
+		return nil, fmt.Errorf("transaction is paid by %s, not by %s", tx.Payer.ToBase58(),
+			payer.Address().ToBase58())
+	}
+	if err = payer.SignTransaction(tx); err != nil {
+		return nil, err
+	}
+	return encodeTransaction(tx)
+}
+
+// SubmitTransaction broadcasts tx, once co-signed by its payer, and waits for
+// its confirmation.
+func (c *Core) SubmitTransaction(tx *ExportedTx) ([]byte, error) {
+	return c.SubmitTransactionContext(context.Background(), tx)
+}
+
+func (c *Core) SubmitTransactionContext(ctx context.Context, tx *ExportedTx) ([]byte, error) {
+	pending, err := c.SubmitTransactionAsync(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	return pending.result(ctx)
+}
+
+func (c *Core) SubmitTransactionAsync(ctx context.Context, tx *ExportedTx) (*PendingTx, error) {
+	backend, ok := c.Backend.(TxBackend)
+	if !ok {
+		return nil, errors.New(tx.Method + " backend cannot send transactions")
+	}
+	mutTx, err := decodeTransaction(tx.Raw)
+	if err != nil {
+		return nil, err
+	}
+	account := tx.Signer
+	if account == ccom.ADDRESS_EMPTY {
+		account = tx.Payer
+	}
+	var txHash ccom.Uint256
+	inv := &Invocation{Kind: InvocationTransaction, Method: tx.Method, Payer: tx.Payer}
+	err = c.intercept(ctx, inv, func(ctx context.Context, inv *Invocation) error {
+		err := c.enqueue(ctx, account, inv.Method, func() error {
+			var err error
+			txHash, err = c.resend(ctx, backend, mutTx)
+			return err
+		})
+		if err == nil {
+			inv.TxHash = txHash.ToArray()
+		}
+		return err
+	})
+	c.observeSubmit(tx.Method, err)
+	if err != nil {
+		return nil, err
+	}
+	return c.trackAsync(ctx, tx.Method, nil, txHash, nil), nil
+}
+
+func encodeTransaction(tx *types.MutableTransaction) ([]byte, error) {
+	immutable, err := tx.IntoImmutable()
+	if err != nil {
+		return nil, err
+	}
+	return immutable.ToArray(), nil
+}
+
+func decodeTransaction(raw []byte) (*types.MutableTransaction, error) {
+	tx, err := types.TransactionFromRawBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode transaction: %s", err.Error())
+	}
+	return tx.IntoMutable()
+}
@@ -0,0 +1,262 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	ccom "github.com/ontio/ontology/common"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// TxReceipt describes a confirmed transaction.
+type TxReceipt struct {
+	TxHash []byte
+	Height uint32
+	Event  *sdkcom.SmartContactEvent
+	// Errors is only set for the batch file methods, whose per-file failures
+	// are reported in contract notifies, and Batch for those submitted by
+	// the methods of a Core.
+	Errors *fs.Errors
+	Batch  *BatchResult
+}
+
+// PendingTx is a submitted transaction whose confirmation is tracked in the
+// background. It is returned by the Async variants of the Core methods.
+type PendingTx struct {
+	method    string
+	params    []interface{}
+	batch     *BatchResult
+	txHash    []byte
+	submitted time.Time
+	done      chan struct{}
+	receipt   *TxReceipt
+	err       error
+}
+
+func (p *PendingTx) Hash() []byte {
+	return p.txHash
+}
+
+// Done is closed once the transaction is confirmed or tracking has failed.
+func (p *PendingTx) Done() <-chan struct{} {
+	return p.done
+}
+
+// Receipt returns the receipt of a confirmed transaction, or nil while the
+// transaction is pending or when it could not be confirmed. It is set along
+// with Err as Wait describes.
+func (p *PendingTx) Receipt() *TxReceipt {
+	select {
+	case <-p.done:
+		return p.receipt
+	default:
+		return nil
+	}
+}
+
+// Err returns the tracking error, or nil while the transaction is pending.
+func (p *PendingTx) Err() error {
+	select {
+	case <-p.done:
+		return p.err
+	default:
+		return nil
+	}
+}
+
+// Wait blocks until the transaction is confirmed, tracking fails or ctx is
+// done. When the batch result of a confirmed transaction cannot be fetched or
+// decoded, its receipt, without Errors and Batch, is returned together with
+// the error.
+func (p *PendingTx) Wait(ctx context.Context) (*TxReceipt, error) {
+	select {
+	case <-p.done:
+		return p.receipt, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WaitAll waits for every transaction in pendings and returns the first error.
+func WaitAll(ctx context.Context, pendings ...*PendingTx) error {
+	var firstErr error
+	for _, pending := range pendings {
+		if _, err := pending.Wait(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return firstErr
+}
+
+func (p *PendingTx) result(ctx context.Context) ([]byte, error) {
+	_, err := p.Wait(ctx)
+	return p.txHash, err
+}
+
+// txStateFailed is the event state of a transaction that failed to execute.
+const txStateFailed = 0
+
+// confirmTimeout returns ConfirmTimeout, or TX_CONFIRM_TIMEOUT when it is not
+// set.
+func (c *Core) confirmTimeout() time.Duration {
+	if c.ConfirmTimeout <= 0 {
+		return time.Duration(common.TX_CONFIRM_TIMEOUT) * time.Second
+	}
+	return c.ConfirmTimeout
+}
+
+// invokeAsync submits a contract invocation and tracks its confirmation in
+// the background until it is confirmed, the confirmation timeout has passed
+// or ctx is done, so ctx must outlive the wait. batch lists the inputs of a
+// batch file method and is nil for the other methods.
+func (c *Core) invokeAsync(ctx context.Context, method string, params []interface{},
+	batch *BatchResult) (*PendingTx, error) {
+	txHash, err := c.submit(ctx, method, params)
+	c.observeSubmit(method, err)
+	if err != nil {
+		return nil, err
+	}
+	return c.trackAsync(ctx, method, params, txHash, batch), nil
+}
+
+// trackAsync returns the PendingTx of a submitted transaction, tracked in the
+// background. params are nil for a transaction built elsewhere.
+func (c *Core) trackAsync(ctx context.Context, method string, params []interface{}, txHash ccom.Uint256,
+	batch *BatchResult) *PendingTx {
+	pending := &PendingTx{
+		method:    method,
+		params:    params,
+		batch:     batch,
+		txHash:    txHash.ToArray(),
+		submitted: time.Now(),
+		done:      make(chan struct{}),
+	}
+	c.logger().Debug("transaction submitted", MethodField(method), TxHashField(pending.txHash))
+	go c.track(ctx, pending)
+	return pending
+}
+
+func (c *Core) track(ctx context.Context, pending *PendingTx) {
+	defer func() {
+		// a failed or unconfirmed transaction may still have changed the state
+		c.invalidateCache(pending)
+		c.observeConfirm(pending)
+		c.logConfirm(pending)
+		close(pending.done)
+	}()
+
+	timeout := c.confirmTimeout()
+	height, err := c.waitConfirmed(ctx, timeout, pending.txHash)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	if err != nil {
+		pending.err = &TxNotConfirmedError{
+			Method:  pending.method,
+			TxHash:  pending.txHash,
+			Timeout: timeout,
+			Err:     err,
+		}
+		return
+	}
+	receipt := &TxReceipt{
+		TxHash: pending.txHash,
+		Height: height,
+	}
+
+	err = c.read(ctx, pending.method, func() error {
+		var err error
+		receipt.Event, err = c.Backend.GetSmartContractEvent(hexTxHash(pending.txHash))
+		return err
+	})
+	batchMethod := pending.batch != nil || isBatchMethod(pending.method)
+	if err != nil || receipt.Event == nil {
+		// the event is only needed for the object errors of batch txs
+		if batchMethod {
+			if err == nil {
+				err = &RPCError{Method: pending.method, Err: errors.New("GetSmartContractEvent error")}
+			}
+			pending.receipt, pending.err = receipt, err
+			return
+		}
+		pending.receipt = receipt
+		return
+	}
+	if receipt.Event.State == txStateFailed {
+		pending.err = &ContractRejectedError{
+			Method:  pending.method,
+			Message: "transaction execution failed",
+			TxHash:  pending.txHash,
+		}
+		return
+	}
+	if !batchMethod {
+		pending.receipt = receipt
+		return
+	}
+
+	objErrors, err := batchErrors(c.contractAddress(), receipt.Event.Notify)
+	if err != nil {
+		pending.receipt, pending.err = receipt, err
+		return
+	}
+	receipt.Errors = objErrors
+	if pending.batch != nil {
+		receipt.Batch = pending.batch.withErrors(pending.txHash, objErrors)
+	}
+	pending.receipt = receipt
+}
+
+func (c *Core) logConfirm(pending *PendingTx) {
+	logger := c.logger()
+	fields := []Field{MethodField(pending.method), TxHashField(pending.txHash)}
+	if pending.err != nil {
+		logger.Warn("transaction failed", append(fields, ErrField(pending.err))...)
+		return
+	}
+	logger.Debug("transaction confirmed", append(fields, F("height", pending.receipt.Height))...)
+	if pending.receipt.Batch == nil {
+		return
+	}
+	for _, item := range pending.receipt.Batch.Failed() {
+		logger.Warn("file rejected", append(fields, FileHashField(item.FileHash),
+			F("reason", item.Message))...)
+	}
+}
+
+// batchErrors merges the object errors of every notify of the ontfs contract
+// at contract.
+func batchErrors(contract ccom.Address, notifies []*sdkcom.NotifyEventInfo) (*fs.Errors, error) {
+	contractStr := contract.ToHexString()
+	objErrors := &fs.Errors{ObjectErrors: make(map[string]string)}
+	found := false
+	for _, notify := range notifies {
+		if 0 != strings.Compare(contractStr, notify.ContractAddress) {
+			continue
+		}
+		errorData, ok := notify.States.(string)
+		if !ok || len(errorData) == 0 {
+			continue
+		}
+		var notifyErrors fs.Errors
+		if err := notifyErrors.FromString(errorData); err != nil {
+			return nil, err
+		}
+		for object, objErr := range notifyErrors.ObjectErrors {
+			objErrors.AddObjectError(object, objErr)
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("GetSmartContractEvent error")
+	}
+	return objErrors, nil
+}
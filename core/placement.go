@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// maxPlacementSets bounds the candidate node sets of a PlacementPlan.
+const maxPlacementSets = 5
+
+// PlacementReason tells why a file or a node does not qualify for placement.
+type PlacementReason string
+
+const (
+	PlacementNoCopies      PlacementReason = "no copy requested"
+	PlacementNoBlocks      PlacementReason = "no block"
+	PlacementInSpace       PlacementReason = "stored in a space"
+	PlacementPdpInterval   PlacementReason = "pdp interval below the minimum"
+	PlacementTooShort      PlacementReason = "expires before the minimum storage time"
+	PlacementNodeVolume    PlacementReason = "nodes without enough rest volume"
+	PlacementNodeService   PlacementReason = "nodes serving until before the file expires"
+	PlacementNodeInterval  PlacementReason = "nodes requiring a longer pdp interval"
+	PlacementNodeNoAddress PlacementReason = "nodes without a valid network address"
+)
+
+// nodePlacementReasons lists the node reasons in the order they are checked.
+var nodePlacementReasons = []PlacementReason{
+	PlacementNodeNoAddress,
+	PlacementNodeService,
+	PlacementNodeInterval,
+	PlacementNodeVolume,
+}
+
+// NodeRejection is a node that cannot hold a copy of a file, for the first
+// reason found.
+type NodeRejection struct {
+	Node   Node
+	Reason PlacementReason
+}
+
+// PlacementPlan lists the nodes able to hold the copies of a file.
+type PlacementPlan struct {
+	FileHash string
+	Copies   uint64
+	// SizeKB is the volume one copy takes on a node.
+	SizeKB uint64
+	// Candidates are the nodes that qualify, with the most rest volume first.
+	Candidates []Node
+	// Sets are up to 5 sets of Copies candidates, the best first.
+	Sets     [][]Node
+	Rejected []NodeRejection
+}
+
+// PlanPlacement checks that Copies of file can be held by nodes, given the
+// global parameters of the contract. Each copy needs a node with a parsable
+// network address, serving until the file expires, accepting its pdp
+// interval and with enough rest volume. now, the chain time in seconds, is
+// used to check the minimum storage time unless zero.
+//
+// The nodes prove the files they choose, so the plan tells whether placement
+// is possible and which nodes can take part, not where copies will go. A
+// *PlacementError explaining the shortfall is returned with the plan when
+// placement is impossible.
+func PlanPlacement(file common.FileStore, global *fs.FsGlobalParam, nodes []fs.FsNodeInfo,
+	now uint64) (*PlacementPlan, error) {
+	plan := &PlacementPlan{
+		FileHash: file.FileHash,
+		Copies:   file.CopyNumber,
+		SizeKB:   file.FileBlockCount * common.FILE_BLOCK_SIZE_KB,
+	}
+	fileErr := func(reason PlacementReason) error {
+		return &PlacementError{FileHash: file.FileHash, Copies: file.CopyNumber, File: reason, Nodes: len(nodes)}
+	}
+	switch {
+	case file.StorageType == fs.FileStorageTypeUseSpace:
+		return plan, fileErr(PlacementInSpace)
+	case file.CopyNumber == 0:
+		return plan, fileErr(PlacementNoCopies)
+	case file.FileBlockCount == 0:
+		return plan, fileErr(PlacementNoBlocks)
+	case file.PdpInterval < defaultMinPdpInterval:
+		return plan, fileErr(PlacementPdpInterval)
+	case now != 0 && global != nil && file.TimeExpired < now+global.MinTimeForFileStorage:
+		return plan, fileErr(PlacementTooShort)
+	}
+
+	reasons := make(map[PlacementReason]int)
+	for _, info := range nodes {
+		node := Node{FsNodeInfo: info}
+		node.Endpoint, _ = ParseNodeEndpoint(string(info.NodeNetAddr))
+		var reason PlacementReason
+		switch {
+		case node.Endpoint == nil:
+			reason = PlacementNodeNoAddress
+		case node.ServiceTime < file.TimeExpired:
+			reason = PlacementNodeService
+		case node.MinPdpInterval > file.PdpInterval:
+			reason = PlacementNodeInterval
+		case node.RestVol < plan.SizeKB:
+			reason = PlacementNodeVolume
+		}
+		if len(reason) != 0 {
+			reasons[reason]++
+			plan.Rejected = append(plan.Rejected, NodeRejection{Node: node, Reason: reason})
+			continue
+		}
+		plan.Candidates = append(plan.Candidates, node)
+	}
+	sort.SliceStable(plan.Candidates, func(i, j int) bool {
+		return plan.Candidates[i].RestVol > plan.Candidates[j].RestVol
+	})
+
+	if uint64(len(plan.Candidates)) < file.CopyNumber {
+		return plan, &PlacementError{
+			FileHash: file.FileHash,
+			Copies:   file.CopyNumber,
+			Eligible: len(plan.Candidates),
+			Nodes:    len(nodes),
+			Reasons:  reasons,
+		}
+	}
+	plan.Sets = candidateSets(plan.Candidates, int(file.CopyNumber), maxPlacementSets)
+	return plan, nil
+}
+
+// candidateSets returns the first max combinations of size nodes of
+// candidates, in lexicographic order of their indexes.
+func candidateSets(candidates []Node, size int, max int) [][]Node {
+	indexes := make([]int, size)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	var sets [][]Node
+	for len(sets) < max {
+		set := make([]Node, size)
+		for i, index := range indexes {
+			set[i] = candidates[index]
+		}
+		sets = append(sets, set)
+
+		// advance the rightmost index that can move
+		i := size - 1
+		for i >= 0 && indexes[i] == len(candidates)-size+i {
+			i--
+		}
+		if i < 0 {
+			break
+		}
+		indexes[i]++
+		for j := i + 1; j < size; j++ {
+			indexes[j] = indexes[j-1] + 1
+		}
+	}
+	return sets
+}
+
+// PlanPlacements runs PlanPlacement on every file of files not stored in a
+// space, in order. The volume of the copies of each file is deducted from the
+// rest volume of the candidates with the most of it before the next file is
+// planned, so that files fitting alone but not together are caught. nodes is
+// left untouched. The plans are returned up to the first file that cannot be
+// placed, along with its error.
+func PlanPlacements(files []common.FileStore, global *fs.FsGlobalParam, nodes []fs.FsNodeInfo,
+	now uint64) ([]*PlacementPlan, error) {
+	rest := make([]fs.FsNodeInfo, len(nodes))
+	copy(rest, nodes)
+	var plans []*PlacementPlan
+	for _, file := range files {
+		if file.StorageType == fs.FileStorageTypeUseSpace {
+			continue
+		}
+		plan, err := PlanPlacement(file, global, rest, now)
+		plans = append(plans, plan)
+		if err != nil {
+			return plans, err
+		}
+		for _, node := range plan.Sets[0] {
+			for i := range rest {
+				if rest[i].NodeAddr == node.NodeAddr {
+					rest[i].RestVol -= plan.SizeKB
+				}
+			}
+		}
+	}
+	return plans, nil
+}
+
+// checkPlacement runs PlanPlacements on filesInfo against the current global
+// parameters, nodes and chain time.
+func (c *Core) checkPlacement(ctx context.Context, filesInfo []common.FileStore) error {
+	if !c.PlacementCheck {
+		return nil
+	}
+	global, err := c.GetGlobalParamContext(ctx)
+	if err != nil {
+		return err
+	}
+	nodes, err := c.nodeInfoList(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := c.chainTime(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = PlanPlacements(filesInfo, global, nodes, now)
+	return err
+}
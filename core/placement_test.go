@@ -0,0 +1,156 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
+	ont "github.com/ontio/ontology-go-sdk"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+func TestPlanPlacement(t *testing.T) {
+	node := func(restVol, serviceTime, minPdpInterval uint64) fs.FsNodeInfo {
+		return fs.FsNodeInfo{
+			RestVol:        restVol,
+			ServiceTime:    serviceTime,
+			MinPdpInterval: minPdpInterval,
+			NodeAddr:       ont.NewAccount().Address,
+			NodeNetAddr:    []byte("tcp://127.0.0.1:3389"),
+		}
+	}
+	nodes := []fs.FsNodeInfo{
+		node(1024, 2000000, 600),
+		node(64*1024, 2000000, 600),
+		node(64*1024, 1500000, 600),
+		node(64*1024, 2000000, 3600),
+		node(128*1024, 2000000, 600),
+	}
+	file := common.FileStore{
+		FileHash:       "QmFile",
+		FileBlockCount: 16,
+		CopyNumber:     2,
+		PdpInterval:    600,
+		TimeExpired:    1800000,
+		StorageType:    fs.FileStorageTypeUseFile,
+	}
+	global := &fs.FsGlobalParam{MinTimeForFileStorage: 3600}
+
+	plan, err := core.PlanPlacement(file, global, nodes, 1000000)
+	if err != nil {
+		t.Fatalf("PlanPlacement error: %s", err.Error())
+	}
+	if len(plan.Candidates) != 2 || plan.Candidates[0].RestVol != 128*1024 || len(plan.Sets) != 1 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	file.CopyNumber = 3
+	_, err = core.PlanPlacement(file, global, nodes, 1000000)
+	var placementErr *core.PlacementError
+	if !errors.As(err, &placementErr) {
+		t.Fatalf("expected a PlacementError, got %v", err)
+	}
+	if placementErr.Eligible != 2 || placementErr.Reasons[core.PlacementNodeVolume] != 1 ||
+		placementErr.Reasons[core.PlacementNodeService] != 1 || placementErr.Reasons[core.PlacementNodeInterval] != 1 {
+		t.Fatalf("unexpected reasons: %s", err.Error())
+	}
+
+	if _, err = core.PlanPlacement(file, global, nodes, 1799000); !errors.As(err, &placementErr) ||
+		placementErr.File != core.PlacementTooShort {
+		t.Fatalf("expected a too short storage time, got %v", err)
+	}
+}
+
+func TestPlanPlacements(t *testing.T) {
+	nodes := []fs.FsNodeInfo{{
+		RestVol:        40 * 256,
+		ServiceTime:    2000000,
+		MinPdpInterval: 600,
+		NodeAddr:       ont.NewAccount().Address,
+		NodeNetAddr:    []byte("tcp://127.0.0.1:3389"),
+	}}
+	file := func(hash string, storageType uint64) common.FileStore {
+		return common.FileStore{
+			FileHash:       hash,
+			FileBlockCount: 16,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			TimeExpired:    1800000,
+			StorageType:    storageType,
+		}
+	}
+
+	// each file fits alone, the third does not fit after the first two
+	files := []common.FileStore{
+		file("QmFirst", fs.FileStorageTypeUseFile),
+		file("QmInSpace", fs.FileStorageTypeUseSpace),
+		file("QmSecond", fs.FileStorageTypeUseFile),
+		file("QmThird", fs.FileStorageTypeUseFile),
+	}
+	plans, err := core.PlanPlacements(files, nil, nodes, 0)
+	var placementErr *core.PlacementError
+	if !errors.As(err, &placementErr) || placementErr.FileHash != "QmThird" ||
+		placementErr.Reasons[core.PlacementNodeVolume] != 1 {
+		t.Fatalf("expected the third file to be rejected, got %v", err)
+	}
+	if len(plans) != 3 || plans[1].Candidates[0].RestVol != 24*256 {
+		t.Fatalf("unexpected plans: %+v", plans)
+	}
+	if nodes[0].RestVol != 40*256 {
+		t.Fatalf("nodes changed: rest volume %d", nodes[0].RestVol)
+	}
+
+	if _, err = core.PlanPlacements(files[:3], nil, nodes, 0); err != nil {
+		t.Fatalf("PlanPlacements error: %s", err.Error())
+	}
+}
+
+func TestStoreFiles_PlacementCheck(t *testing.T) {
+	_, c := newSimCore(t, false)
+	c.PlacementCheck = true
+	_, err := c.StoreFilesBatch([]common.FileStore{{
+		FileHash:       "QmFile",
+		FileBlockCount: 16,
+		CopyNumber:     1,
+		PdpInterval:    600,
+		TimeExpired:    4102444800,
+		StorageType:    fs.FileStorageTypeUseFile,
+	}})
+	var placementErr *core.PlacementError
+	if !errors.As(err, &placementErr) || placementErr.Nodes != 0 {
+		t.Fatalf("expected a PlacementError without nodes, got %v", err)
+	}
+}
+
+func TestStoreFiles_PlacementCheckSeveralFiles(t *testing.T) {
+	_, c := newSimCore(t, false)
+	c.PlacementCheck = true
+	if _, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	var files []common.FileStore
+	for i := 0; i < 3; i++ {
+		files = append(files, common.FileStore{
+			FileHash: fmt.Sprintf("QmPlacedFile%d", i),
+			// half the volume of the node
+			FileBlockCount: 1024 * 1024 / 2 / common.FILE_BLOCK_SIZE_KB,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			// valid against the chain time, long expired by the local clock
+			TimeExpired: 1577836800 + 3*3600,
+			StorageType: fs.FileStorageTypeUseFile,
+		})
+	}
+
+	_, err := c.StoreFilesBatch(files)
+	var placementErr *core.PlacementError
+	if !errors.As(err, &placementErr) || placementErr.FileHash != "QmPlacedFile2" {
+		t.Fatalf("expected the third file to be rejected, got %v", err)
+	}
+	result, err := c.StoreFilesBatch(files[:2])
+	if err != nil || !result.AllSucceeded() {
+		t.Fatalf("StoreFilesBatch result %+v, error %v", result, err)
+	}
+}
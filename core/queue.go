@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	ccom "github.com/ontio/ontology/common"
+)
+
+// submitQueue lets the transactions of one account be signed and broadcast
+// one at a time, in the order they were submitted.
+type submitQueue struct {
+	turn chan struct{}
+	// users counts the callers holding or waiting for the turn. The queue is
+	// dropped when it falls to zero.
+	users int
+}
+
+// submitQueues holds the queues of the accounts a Core and its views are
+// submitting for.
+type submitQueues struct {
+	lock   sync.Mutex
+	queues map[ccom.Address]*submitQueue
+}
+
+// defaultSubmitQueues serves the Cores not created by New or InitWithBackend.
+var defaultSubmitQueues = newSubmitQueues()
+
+func newSubmitQueues() *submitQueues {
+	return &submitQueues{queues: make(map[ccom.Address]*submitQueue)}
+}
+
+func (s *submitQueues) acquire(addr ccom.Address) *submitQueue {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	queue, ok := s.queues[addr]
+	if !ok {
+		queue = &submitQueue{turn: make(chan struct{}, 1)}
+		s.queues[addr] = queue
+	}
+	queue.users++
+	return queue
+}
+
+func (s *submitQueues) release(addr ccom.Address, queue *submitQueue) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	queue.users--
+	if queue.users == 0 {
+		delete(s.queues, addr)
+	}
+}
+
+// run waits for the turn of the caller in the queue of addr and runs fn. Like
+// callContext it returns ctx.Err() as soon as ctx is done, but the queue is
+// only passed on once fn has returned.
+func (s *submitQueues) run(ctx context.Context, addr ccom.Address, fn func() error) error {
+	queue := s.acquire(addr)
+	select {
+	case queue.turn <- struct{}{}:
+	case <-ctx.Done():
+		s.release(addr, queue)
+		return ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		<-queue.turn
+		s.release(addr, queue)
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			<-queue.turn
+			s.release(addr, queue)
+		}()
+		done <- fn()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 5 * time.Second
+	defaultRetryMultiplier     = 2
+)
+
+// RetryPolicy controls how the calls to the node that are safe to repeat are
+// retried after a transient failure: queries, pre-executions, event fetches
+// and the resending of a transaction already signed. The zero RetryPolicy
+// makes a single attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts of a call, the first included.
+	MaxAttempts int
+	// The n-th retry waits InitialBackoff * Multiplier^(n-1), at most
+	// MaxBackoff, randomized by +/- Jitter times that wait. Zero selects
+	// 100ms, 5s and 2 respectively, and no jitter.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+	// Retryable reports whether a failure is transient. It is
+	// IsTransientError when nil.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy makes up to 4 attempts over about 1.4s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// transientErrorMessages are the failures the sdk reports as text: the
+// prefixes of its RPC client errors and the messages of the network errors
+// they wrap.
+var transientErrorMessages = []string{
+	"send http post request error",
+	"read http body error",
+	"connection refused",
+	"connection reset by peer",
+	"broken pipe",
+	"i/o timeout",
+	"unexpected EOF",
+}
+
+// IsTransientError reports whether err is a network failure, as opposed to
+// an error of the contract or of the request. The sdk returns most network
+// failures as text, so the message is matched against the known transport
+// messages as well.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var contractErr *ContractRejectedError
+	if errors.As(err, &contractErr) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	message := err.Error()
+	if message == "EOF" || strings.HasSuffix(message, ": EOF") {
+		return true
+	}
+	for _, transient := range transientErrorMessages {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDuplicateTxError reports whether a node refused a transaction because it
+// already holds it.
+func isDuplicateTxError(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "duplicated transaction") || strings.Contains(message, "duplicate tx")
+}
+
+// do runs fn until it succeeds, fails with an error that is not retryable or
+// MaxAttempts is reached. It returns ctx.Err() if ctx is done while waiting.
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	err := fn()
+	for attempt := 1; err != nil && attempt < p.MaxAttempts && p.retryable(err); attempt++ {
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		err = fn()
+	}
+	return err
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsTransientError(err)
+}
+
+// backoff returns the wait before the retry-th retry.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	initial, maxBackoff, multiplier := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial <= 0 {
+		initial = defaultRetryInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	if multiplier < 1 {
+		multiplier = defaultRetryMultiplier
+	}
+	wait := float64(initial) * math.Pow(multiplier, float64(retry-1))
+	if wait > float64(maxBackoff) {
+		wait = float64(maxBackoff)
+	}
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(wait)
+}
+
+// read is rpc for the calls that are safe to repeat, retried according to
+// the Retry policy of c.
+func (c *Core) read(ctx context.Context, method string, fn func() error) error {
+	attempt := 0
+	var lastErr error
+	return c.Retry.do(ctx, func() error {
+		attempt++
+		if attempt > 1 {
+			c.logger().Debug("retrying call", MethodField(method), F("attempt", attempt), ErrField(lastErr))
+		}
+		lastErr = c.rpc(ctx, method, fn)
+		return lastErr
+	})
+}
@@ -0,0 +1,147 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontfs-contract-api/sim"
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+// flakyBackend fails the first calls of each kind with err.
+type flakyBackend struct {
+	*sim.Simulator
+	err error
+
+	lock          sync.Mutex
+	queryFailures int
+	sendFailures  int
+	// lostReplies makes the failed sends reach the chain, whose node then
+	// refuses them as duplicates
+	lostReplies bool
+	queries     int
+	sends       []ccom.Uint256
+	accepted    map[ccom.Uint256]bool
+}
+
+func (b *flakyBackend) PreExecInvokeNativeContract(contractAddress ccom.Address, version byte, method string,
+	params []interface{}) (*sdkcom.PreExecResult, error) {
+	b.lock.Lock()
+	b.queries++
+	fail := b.queries <= b.queryFailures
+	b.lock.Unlock()
+	if fail {
+		return nil, b.err
+	}
+	return b.Simulator.PreExecInvokeNativeContract(contractAddress, version, method, params)
+}
+
+func (b *flakyBackend) SendTransaction(tx *types.MutableTransaction) (ccom.Uint256, error) {
+	b.lock.Lock()
+	b.sends = append(b.sends, tx.Hash())
+	fail := len(b.sends) <= b.sendFailures
+	duplicate := b.accepted[tx.Hash()]
+	if b.lostReplies {
+		if b.accepted == nil {
+			b.accepted = make(map[ccom.Uint256]bool)
+		}
+		b.accepted[tx.Hash()] = true
+	}
+	b.lock.Unlock()
+	if duplicate {
+		return ccom.UINT256_EMPTY, errors.New("duplicated transaction detected")
+	}
+	if fail {
+		if b.lostReplies {
+			b.Simulator.SendTransaction(tx)
+		}
+		return ccom.UINT256_EMPTY, b.err
+	}
+	return b.Simulator.SendTransaction(tx)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	backend := &flakyBackend{
+		Simulator:     sim.NewSimulator(simConfig()),
+		err:           errors.New("send http post request error: dial tcp: connection refused"),
+		queryFailures: 2,
+		sendFailures:  1,
+	}
+	c := newTestCore(t, backend)
+	c.Retry = core.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	if _, err := c.GetGlobalParam(); err != nil {
+		t.Fatalf("GetGlobalParam error: %s", err.Error())
+	}
+	if backend.queries != 3 {
+		t.Fatalf("expected 3 attempts, got %d", backend.queries)
+	}
+
+	if _, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	if len(backend.sends) != 2 || backend.sends[0] != backend.sends[1] {
+		t.Fatalf("broadcast not resent unchanged: %v", backend.sends)
+	}
+
+	// contract errors are not retried
+	backend.queries, backend.queryFailures = 0, 0
+	if _, err := c.GetNodeInfo(ccom.ADDRESS_EMPTY); err == nil {
+		t.Fatalf("GetNodeInfo of an unknown node succeeded")
+	}
+	if backend.queries != 1 {
+		t.Fatalf("contract error retried: %d attempts", backend.queries)
+	}
+}
+
+func TestRetryPolicy_LostReply(t *testing.T) {
+	backend := &flakyBackend{
+		Simulator:    sim.NewSimulator(simConfig()),
+		err:          errors.New("send http post request error: read: connection reset by peer"),
+		sendFailures: 1,
+		lostReplies:  true,
+	}
+	c := newTestCore(t, backend)
+	c.Retry = core.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	// the first send reached the node: the duplicate resend counts as sent
+	if _, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	if len(backend.sends) != 2 {
+		t.Fatalf("expected 2 sends, got %d", len(backend.sends))
+	}
+	if _, err := c.GetNodeInfo(c.WalletAddr); err != nil {
+		t.Fatalf("GetNodeInfo error: %s", err.Error())
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	if !core.IsTransientError(&core.RPCError{Method: "m", Err: errors.New("read: connection reset by peer")}) {
+		t.Fatalf("connection reset is not transient")
+	}
+	if core.IsTransientError(&core.ContractRejectedError{Method: "m", Message: "timeout"}) {
+		t.Fatalf("contract rejection is transient")
+	}
+	if !core.IsTransientError(fmt.Errorf("send http post request error: %w", io.EOF)) {
+		t.Fatalf("EOF is not transient")
+	}
+	if !core.IsTransientError(errors.New("read http body error:unexpected EOF")) {
+		t.Fatalf("truncated body is not transient")
+	}
+	// a node error merely mentioning a timeout or a proof is an answer
+	if core.IsTransientError(errors.New("FsFileProve challenge timeout, invalid proof of file")) {
+		t.Fatalf("node error is transient")
+	}
+	if core.IsTransientError(context.Canceled) {
+		t.Fatalf("cancellation is transient")
+	}
+}
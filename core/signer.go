@@ -0,0 +1,97 @@
+package core
+
+import (
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontology-crypto/keypair"
+	ont "github.com/ontio/ontology-go-sdk"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+// Signer signs transactions, passports and read settle slices for one
+// account. It lets the private key live outside the process, such as in the
+// signing daemon of the signer package.
+type Signer interface {
+	PublicKey() keypair.PublicKey
+	Address() ccom.Address
+	// Sign returns the serialized signature of data, as common.Sign does.
+	Sign(data []byte) ([]byte, error)
+	// SignTransaction adds the signature of the account to tx.
+	SignTransaction(tx *types.MutableTransaction) error
+}
+
+// LocalSigner is a Signer backed by an in-process account.
+type LocalSigner struct {
+	Account *ont.Account
+}
+
+func NewLocalSigner(acc *ont.Account) *LocalSigner {
+	return &LocalSigner{Account: acc}
+}
+
+func (s *LocalSigner) PublicKey() keypair.PublicKey {
+	return s.Account.PublicKey
+}
+
+func (s *LocalSigner) Address() ccom.Address {
+	return s.Account.Address
+}
+
+func (s *LocalSigner) Sign(data []byte) ([]byte, error) {
+	return common.Sign(s.Account, data)
+}
+
+func (s *LocalSigner) SignTransaction(tx *types.MutableTransaction) error {
+	return SignTransaction(s, tx)
+}
+
+// SignTransaction signs tx with signer the way the sdk signs with an account:
+// the signer pays for tx unless a payer is already set, and the signature of
+// the transaction hash is appended to the signatures of tx.
+func SignTransaction(signer Signer, tx *types.MutableTransaction) error {
+	if tx.Payer == ccom.ADDRESS_EMPTY {
+		tx.Payer = signer.Address()
+	}
+	txHash := tx.Hash()
+	sigData, err := signer.Sign(txHash.ToArray())
+	if err != nil {
+		return err
+	}
+	tx.Sigs = append(tx.Sigs, types.Sig{
+		PubKeys: []keypair.PublicKey{signer.PublicKey()},
+		M:       1,
+		SigData: [][]byte{sigData},
+	})
+	return nil
+}
+
+// WithSigner returns a view of c that signs with signer and uses its address
+// as the file owner, node address and space owner.
+func (c *Core) WithSigner(signer Signer) *Core {
+	view := *c
+	view.Signer = signer
+	view.DefAcc = nil
+	view.WalletAddr = signer.Address()
+	return &view
+}
+
+// signer returns Signer, or a LocalSigner for DefAcc when it is not set. It
+// returns nil when c has no account to sign with.
+func (c *Core) signer() Signer {
+	if c.Signer != nil {
+		return c.Signer
+	}
+	if c.DefAcc != nil {
+		return NewLocalSigner(c.DefAcc)
+	}
+	return nil
+}
+
+// accountAddress returns the account of the signer of c, or WalletAddr when c
+// has no signer.
+func (c *Core) accountAddress() ccom.Address {
+	if signer := c.signer(); signer != nil {
+		return signer.Address()
+	}
+	return c.WalletAddr
+}
@@ -0,0 +1,321 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsActionSubscribe     = "subscribe"
+	wsActionHeartbeat     = "heartbeat"
+	wsActionBlockTxHashes = "sendblocktxhashs"
+	wsVersion             = "1.0"
+
+	trackerPollInterval      = time.Second
+	trackerHeartbeatInterval = 60 * time.Second
+	trackerReconnectDelay    = 3 * time.Second
+	trackerRecentBlocks      = 64
+)
+
+type wsSubscribe struct {
+	Action                string
+	Version               string
+	ConstractsFilter      []string
+	SubscribeEvent        bool
+	SubscribeJsonBlock    bool
+	SubscribeRawBlock     bool
+	SubscribeBlockTxHashs bool
+}
+
+type wsHeartbeat struct {
+	Action  string
+	Version string
+}
+
+type wsResponse struct {
+	Action  string
+	Desc    string
+	Error   int64
+	Result  json.RawMessage
+	Version string
+}
+
+type wsBlockTxHashes struct {
+	Height    uint32
+	BlockHash string
+	TxHashes  []string
+}
+
+// ConfirmTracker resolves the confirmation of every transaction submitted by
+// a Core from one shared stream of new blocks, received from the websocket
+// api of an Ontology node. While the websocket is not connected it falls back
+// to polling the pending transactions through the ChainBackend.
+type ConfirmTracker struct {
+	wsAddr  string
+	backend ChainBackend
+	// Logger receives the websocket connection failures, DefaultLogger when
+	// nil.
+	Logger Logger
+
+	lock      sync.Mutex
+	connected bool
+	waiters   map[string][]chan uint32
+	recent    map[string]uint32
+	recentTxs [][]string
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewConfirmTracker(wsAddr string, backend ChainBackend) *ConfirmTracker {
+	return &ConfirmTracker{
+		wsAddr:  wsAddr,
+		backend: backend,
+		waiters: make(map[string][]chan uint32),
+		recent:  make(map[string]uint32),
+		closeCh: make(chan struct{}),
+	}
+}
+
+// StartConfirmTracker makes c wait for confirmations through a ConfirmTracker
+// subscribed to the websocket api at wsAddr, such as "ws://127.0.0.1:20335".
+// It sets c.Tracker, so it must be called before c is used concurrently; the
+// views derived from c before the call keep waiting without it.
+func (c *Core) StartConfirmTracker(wsAddr string) *ConfirmTracker {
+	tracker := NewConfirmTracker(wsAddr, c.Backend)
+	tracker.Logger = c.logger()
+	tracker.Start()
+	c.Tracker = tracker
+	return tracker
+}
+
+func (t *ConfirmTracker) Start() {
+	t.wg.Add(2)
+	go t.subscribeLoop()
+	go t.pollLoop()
+}
+
+func (t *ConfirmTracker) logger() Logger {
+	if t.Logger != nil {
+		return t.Logger
+	}
+	return DefaultLogger()
+}
+
+// Close stops the tracker. It may be called more than once.
+func (t *ConfirmTracker) Close() {
+	t.closeOnce.Do(func() {
+		close(t.closeCh)
+	})
+	t.wg.Wait()
+}
+
+// Connected reports whether the websocket subscription is active.
+func (t *ConfirmTracker) Connected() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.connected
+}
+
+// Wait returns the height of the block holding txHash, given in the hex form
+// used by the node apis.
+func (t *ConfirmTracker) Wait(ctx context.Context, txHash string, timeout time.Duration) (uint32, error) {
+	ch := make(chan uint32, 1)
+	t.lock.Lock()
+	if height, ok := t.recent[txHash]; ok {
+		t.lock.Unlock()
+		return height, nil
+	}
+	t.waiters[txHash] = append(t.waiters[txHash], ch)
+	t.lock.Unlock()
+	defer t.removeWaiter(txHash, ch)
+
+	// the block may have been produced before the waiter was registered
+	if height, err := t.backend.GetBlockHeightByTxHash(txHash); err == nil && height != 0 {
+		return height, nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case height := <-ch:
+		return height, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-timer.C:
+		return 0, fmt.Errorf("timeout after %d (s)", int(timeout/time.Second))
+	case <-t.closeCh:
+		return 0, fmt.Errorf("confirm tracker is closed")
+	}
+}
+
+func (t *ConfirmTracker) removeWaiter(txHash string, ch chan uint32) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	waiters := t.waiters[txHash]
+	for i, waiter := range waiters {
+		if waiter == ch {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(t.waiters, txHash)
+	} else {
+		t.waiters[txHash] = waiters
+	}
+}
+
+// resolve records the transactions of a new block and wakes their waiters.
+func (t *ConfirmTracker) resolve(height uint32, txHashes []string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, txHash := range txHashes {
+		t.recent[txHash] = height
+		for _, ch := range t.waiters[txHash] {
+			ch <- height
+		}
+		delete(t.waiters, txHash)
+	}
+	t.recentTxs = append(t.recentTxs, txHashes)
+	if len(t.recentTxs) > trackerRecentBlocks {
+		for _, txHash := range t.recentTxs[0] {
+			delete(t.recent, txHash)
+		}
+		t.recentTxs = t.recentTxs[1:]
+	}
+}
+
+func (t *ConfirmTracker) setConnected(connected bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.connected = connected
+}
+
+func (t *ConfirmTracker) subscribeLoop() {
+	defer t.wg.Done()
+	for {
+		err := t.subscribe()
+		t.setConnected(false)
+		select {
+		case <-t.closeCh:
+			return
+		default:
+		}
+		if err != nil {
+			t.logger().Warn("confirm tracker disconnected", F("ws_addr", t.wsAddr), ErrField(err))
+		}
+		select {
+		case <-t.closeCh:
+			return
+		case <-time.After(trackerReconnectDelay):
+		}
+	}
+}
+
+// subscribe connects to the websocket api and handles pushed blocks until
+// the connection fails or the tracker is closed.
+func (t *ConfirmTracker) subscribe() error {
+	conn, _, err := websocket.DefaultDialer.Dial(t.wsAddr, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = conn.WriteJSON(&wsSubscribe{
+		Action:                wsActionSubscribe,
+		Version:               wsVersion,
+		ConstractsFilter:      []string{},
+		SubscribeBlockTxHashs: true,
+	})
+	if err != nil {
+		return err
+	}
+	t.setConnected(true)
+
+	done := make(chan struct{})
+	defer close(done)
+	heartbeatErr := make(chan error, 1)
+	go func() {
+		ticker := time.NewTicker(trackerHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				err := conn.WriteJSON(&wsHeartbeat{Action: wsActionHeartbeat, Version: wsVersion})
+				if err != nil {
+					// a dead connection may never fail the read: closing it
+					// makes the tracker reconnect
+					heartbeatErr <- err
+					conn.Close()
+					return
+				}
+			case <-t.closeCh:
+				conn.Close()
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		var resp wsResponse
+		if err = conn.ReadJSON(&resp); err != nil {
+			select {
+			case err = <-heartbeatErr:
+				return fmt.Errorf("heartbeat: %w", err)
+			default:
+				return err
+			}
+		}
+		if resp.Action != wsActionBlockTxHashes || resp.Error != 0 {
+			continue
+		}
+		var block wsBlockTxHashes
+		if err = json.Unmarshal(resp.Result, &block); err != nil {
+			continue
+		}
+		t.resolve(block.Height, block.TxHashes)
+	}
+}
+
+// pollLoop checks the pending transactions through the backend while the
+// websocket is not connected.
+func (t *ConfirmTracker) pollLoop() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(trackerPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		t.lock.Lock()
+		if t.connected {
+			t.lock.Unlock()
+			continue
+		}
+		txHashes := make([]string, 0, len(t.waiters))
+		for txHash := range t.waiters {
+			txHashes = append(txHashes, txHash)
+		}
+		t.lock.Unlock()
+
+		for _, txHash := range txHashes {
+			height, err := t.backend.GetBlockHeightByTxHash(txHash)
+			if err != nil || height == 0 {
+				continue
+			}
+			t.resolve(height, []string{txHash})
+		}
+	}
+}
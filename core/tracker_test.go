@@ -0,0 +1,137 @@
+package core_test
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ontio/ontfs-contract-api/core"
+	ccom "github.com/ontio/ontology/common"
+)
+
+// wsStandIn is a stand-in for the websocket api of an Ontology node. Every
+// string sent on blocks is pushed as a block holding that transaction.
+type wsStandIn struct {
+	server     *httptest.Server
+	subscribed chan struct{}
+	blocks     chan string
+}
+
+func newWsStandIn(t *testing.T) *wsStandIn {
+	s := &wsStandIn{
+		subscribed: make(chan struct{}, 1),
+		blocks:     make(chan string, 8),
+	}
+	upgrader := websocket.Upgrader{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade error: %s", err.Error())
+			return
+		}
+		defer conn.Close()
+
+		var req map[string]interface{}
+		if err = conn.ReadJSON(&req); err != nil || req["Action"] != "subscribe" {
+			t.Errorf("unexpected subscribe request: %v, %v", req, err)
+			return
+		}
+		s.subscribed <- struct{}{}
+
+		height := uint32(100)
+		for txHash := range s.blocks {
+			height++
+			err = conn.WriteJSON(map[string]interface{}{
+				"Action":  "sendblocktxhashs",
+				"Desc":    "SUCCESS",
+				"Error":   0,
+				"Version": "1.0",
+				"Result": map[string]interface{}{
+					"Height":    height,
+					"BlockHash": "",
+					"TxHashes":  []string{txHash},
+				},
+			})
+			if err != nil {
+				return
+			}
+		}
+	}))
+	return s
+}
+
+func (s *wsStandIn) url() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *wsStandIn) close() {
+	close(s.blocks)
+	s.server.Close()
+}
+
+func TestConfirmTracker_WebSocketPush(t *testing.T) {
+	standIn := newWsStandIn(t)
+	defer standIn.close()
+
+	_, c := newSimCore(t, true)
+	tracker := c.StartConfirmTracker(standIn.url())
+	defer tracker.Close()
+
+	select {
+	case <-standIn.subscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("tracker did not subscribe")
+	}
+
+	pendings := make([]*core.PendingTx, 0, 3)
+	for i := 0; i < 3; i++ {
+		pending, err := c.NodeRegisterAsync(context.Background(), 1024*1024, 1577836800+100000, 600,
+			"tcp://127.0.0.1:3389")
+		if err != nil {
+			t.Fatalf("NodeRegisterAsync error: %s", err.Error())
+		}
+		pendings = append(pendings, pending)
+	}
+	// the simulator never mines, so only the pushed blocks can confirm
+	for _, pending := range pendings {
+		standIn.blocks <- hex.EncodeToString(ccom.ToArrayReverse(pending.Hash()))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := core.WaitAll(ctx, pendings...); err != nil {
+		t.Fatalf("WaitAll error: %s", err.Error())
+	}
+	if height := pendings[2].Receipt().Height; height != 103 {
+		t.Fatalf("receipt height %d", height)
+	}
+}
+
+func TestConfirmTracker_PollFallback(t *testing.T) {
+	chain, c := newSimCore(t, true)
+	tracker := c.StartConfirmTracker("ws://127.0.0.1:1")
+	defer tracker.Close()
+
+	pending, err := c.NodeRegisterAsync(context.Background(), 1024*1024, 1577836800+100000, 600,
+		"tcp://127.0.0.1:3389")
+	if err != nil {
+		t.Fatalf("NodeRegisterAsync error: %s", err.Error())
+	}
+	chain.Mine()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := pending.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Wait error: %s", err.Error())
+	}
+	if tracker.Connected() || receipt.Height != chain.Height() {
+		t.Fatalf("connected %v, receipt height %d", tracker.Connected(), receipt.Height)
+	}
+	tracker.Close()
+}
@@ -0,0 +1,266 @@
+// Package metrics implements core.Metrics with collectors exposed in the
+// Prometheus text format, without depending on the Prometheus client.
+//
+//	collector := metrics.NewCollector("ontfs")
+//	c, err := core.New(core.WithMetrics(collector), ...)
+//	server, err := metrics.Serve(":9090", collector)
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontfs-contract-api/core"
+)
+
+const (
+	resultOK    = "ok"
+	resultError = "error"
+
+	stagePreExec = "preexec"
+	stageTx      = "tx"
+)
+
+// DefaultConfirmBuckets are the upper bounds, in seconds, of the buckets of
+// the confirmation time histogram.
+var DefaultConfirmBuckets = []float64{1, 2, 5, 10, 15, 20, 30, 60}
+
+type counter struct {
+	name   string
+	help   string
+	labels []string
+	values map[string]float64
+}
+
+func newCounter(name, help string, labels ...string) *counter {
+	return &counter{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+func (c *counter) add(value float64, labelValues ...string) {
+	c.values[strings.Join(labelValues, "\xff")] += value
+}
+
+func (c *counter) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, strings.Split(key, "\xff")),
+			formatValue(c.values[key]))
+	}
+}
+
+type histogramValue struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type histogram struct {
+	name    string
+	help    string
+	buckets []float64
+	values  map[string]*histogramValue
+}
+
+func (h *histogram) observe(method string, value float64) {
+	v, ok := h.values[method]
+	if !ok {
+		v = &histogramValue{counts: make([]uint64, len(h.buckets))}
+		h.values[method] = v
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			v.counts[i]++
+		}
+	}
+	v.sum += value
+	v.count++
+}
+
+func (h *histogram) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	methods := make([]string, 0, len(h.values))
+	for method := range h.values {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		v := h.values[method]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name,
+				formatLabels([]string{"method", "le"}, []string{method, formatValue(bound)}), v.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name,
+			formatLabels([]string{"method", "le"}, []string{method, "+Inf"}), v.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels([]string{"method"}, []string{method}),
+			formatValue(v.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels([]string{"method"}, []string{method}), v.count)
+	}
+}
+
+// Collector counts the contract calls of the Cores it is set on, labelled by
+// contract method. It is safe for concurrent use and serves its metrics over
+// http.
+type Collector struct {
+	lock          sync.Mutex
+	preExecs      *counter
+	submitted     *counter
+	confirmed     *counter
+	timeouts      *counter
+	rejections    *counter
+	gasConsumed   *counter
+	confirmTime   *histogram
+	confirmFailed *counter
+}
+
+// NewCollector returns a Collector whose metric names start with namespace,
+// "ontfs" when empty.
+func NewCollector(namespace string) *Collector {
+	return NewCollectorWithBuckets(namespace, DefaultConfirmBuckets)
+}
+
+// NewCollectorWithBuckets is NewCollector with the upper bounds, in seconds,
+// of the buckets of the confirmation time histogram.
+func NewCollectorWithBuckets(namespace string, confirmBuckets []float64) *Collector {
+	if len(namespace) == 0 {
+		namespace = "ontfs"
+	}
+	buckets := append([]float64(nil), confirmBuckets...)
+	sort.Float64s(buckets)
+	return &Collector{
+		preExecs: newCounter(namespace+"_preexec_total",
+			"Pre-executed contract calls: queries and gas estimations.", "method", "result"),
+		submitted: newCounter(namespace+"_tx_submitted_total",
+			"Transactions broadcast, or that failed to be.", "method", "result"),
+		confirmed: newCounter(namespace+"_tx_confirmed_total",
+			"Transactions seen in a block.", "method"),
+		timeouts: newCounter(namespace+"_tx_confirm_timeouts_total",
+			"Transactions not seen in a block within the confirmation timeout.", "method"),
+		confirmFailed: newCounter(namespace+"_tx_confirm_errors_total",
+			"Confirmed transactions whose event could not be fetched.", "method"),
+		rejections: newCounter(namespace+"_contract_rejections_total",
+			"Calls rejected by the contract, at pre-execution or in a transaction.", "method", "stage"),
+		gasConsumed: newCounter(namespace+"_tx_gas_consumed_total",
+			"Gas fee consumed by the confirmed transactions, from their events.", "method"),
+		confirmTime: &histogram{
+			name:    namespace + "_tx_confirm_seconds",
+			help:    "Time from the submission of a transaction to its confirmation.",
+			buckets: buckets,
+			values:  make(map[string]*histogramValue),
+		},
+	}
+}
+
+func (m *Collector) ObservePreExec(method string, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.preExecs.add(1, method, result(err))
+	if isRejected(err) {
+		m.rejections.add(1, method, stagePreExec)
+	}
+}
+
+func (m *Collector) ObserveSubmit(method string, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.submitted.add(1, method, result(err))
+}
+
+func (m *Collector) ObserveConfirm(method string, elapsed time.Duration, receipt *core.TxReceipt, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var notConfirmed *core.TxNotConfirmedError
+	if errors.As(err, &notConfirmed) {
+		// a cancelled wait says nothing about the chain
+		if !errors.Is(err, context.Canceled) {
+			m.timeouts.add(1, method)
+		}
+		return
+	}
+	m.confirmed.add(1, method)
+	m.confirmTime.observe(method, elapsed.Seconds())
+	switch {
+	case isRejected(err):
+		m.rejections.add(1, method, stageTx)
+	case err != nil:
+		m.confirmFailed.add(1, method)
+	}
+	if receipt != nil && receipt.Event != nil {
+		m.gasConsumed.add(float64(receipt.Event.GasConsumed), method)
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Collector) WriteTo(w io.Writer) (int64, error) {
+	buf := new(bytes.Buffer)
+	m.lock.Lock()
+	for _, c := range []*counter{m.preExecs, m.submitted, m.confirmed, m.timeouts, m.confirmFailed,
+		m.rejections, m.gasConsumed} {
+		c.write(buf)
+	}
+	m.confirmTime.write(buf)
+	m.lock.Unlock()
+	return buf.WriteTo(w)
+}
+
+func (m *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// Serve exposes collector at http://addr/metrics from a background server.
+// Close or Shutdown the returned server to stop it.
+func Serve(addr string, collector *Collector) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", collector)
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultError
+	}
+	return resultOK
+}
+
+func isRejected(err error) bool {
+	var rejected *core.ContractRejectedError
+	return errors.As(err, &rejected)
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names []string, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(values[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	return fmt.Sprintf("%g", value)
+}
@@ -0,0 +1,58 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontfs-contract-api/metrics"
+	"github.com/ontio/ontfs-contract-api/sim"
+	ont "github.com/ontio/ontology-go-sdk"
+	ccom "github.com/ontio/ontology/common"
+)
+
+func TestCollector(t *testing.T) {
+	cfg := sim.DefaultConfig()
+	cfg.GenesisTime = 1577836800
+	collector := metrics.NewCollector("")
+	c := core.InitWithBackend(sim.NewSimulator(cfg), ont.NewAccount(), 0, 20000)
+	c.Metrics = collector
+
+	if _, err := c.GetGlobalParam(); err != nil {
+		t.Fatalf("GetGlobalParam error: %s", err.Error())
+	}
+	if _, err := c.GetNodeInfo(ccom.ADDRESS_EMPTY); err == nil {
+		t.Fatalf("GetNodeInfo of an unknown node succeeded")
+	}
+	if _, err := c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+
+	server := httptest.NewServer(collector)
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Get error: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll error: %s", err.Error())
+	}
+	text := string(body)
+	for _, line := range []string{
+		`ontfs_preexec_total{method="ofsGetGlobalParam",result="ok"} `,
+		`ontfs_preexec_total{method="ofsNodeQuery",result="error"} 1`,
+		`ontfs_contract_rejections_total{method="ofsNodeQuery",stage="preexec"} 1`,
+		`ontfs_tx_submitted_total{method="ofsNodeRegister",result="ok"} 1`,
+		`ontfs_tx_confirmed_total{method="ofsNodeRegister"} 1`,
+		`ontfs_tx_confirm_seconds_count{method="ofsNodeRegister"} 1`,
+		`ontfs_tx_confirm_seconds_bucket{method="ofsNodeRegister",le="+Inf"} 1`,
+	} {
+		if !strings.Contains(text, line) {
+			t.Fatalf("missing %q in:\n%s", line, text)
+		}
+	}
+}
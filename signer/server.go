@@ -0,0 +1,151 @@
+package signer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+// Server signs with signer, normally a core.LocalSigner, for the
+// RemoteSigners connecting to its Unix socket. Only the owner of the process
+// may connect: the socket is bound with mode 0600 in a private directory, and
+// only then moved to its path.
+type Server struct {
+	signer core.Signer
+
+	lock       sync.Mutex
+	listener   net.Listener
+	socketPath string
+	closed     bool
+	wg         sync.WaitGroup
+}
+
+func NewServer(signer core.Signer) *Server {
+	return &Server{signer: signer}
+}
+
+// Listen creates the socket at socketPath, replacing a stale socket. It fails
+// if anything else is at socketPath, or if a server still listens there.
+func (s *Server) Listen(socketPath string) error {
+	if err := removeStaleSocket(socketPath); err != nil {
+		return err
+	}
+	// bind in a directory only the owner can enter, so the socket is never
+	// reachable before its mode is set
+	dir, err := os.MkdirTemp(filepath.Dir(socketPath), ".signer-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err = os.Chmod(dir, 0700); err != nil {
+		return err
+	}
+	bindPath := filepath.Join(dir, "s")
+	listener, err := net.Listen("unix", bindPath)
+	if err != nil {
+		return err
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err = os.Chmod(bindPath, 0600); err == nil {
+		err = os.Rename(bindPath, socketPath)
+	}
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	s.lock.Lock()
+	s.listener = listener
+	s.socketPath = socketPath
+	s.lock.Unlock()
+	return nil
+}
+
+// removeStaleSocket removes the socket at socketPath when no server accepts
+// connections on it.
+func removeStaleSocket(socketPath string) error {
+	info, err := os.Lstat(socketPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", socketPath)
+	}
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another server", socketPath)
+	}
+	return os.Remove(socketPath)
+}
+
+// Serve handles connections until Close is called.
+func (s *Server) Serve() error {
+	s.lock.Lock()
+	listener := s.listener
+	s.lock.Unlock()
+	if listener == nil {
+		return fmt.Errorf("signer server is not listening")
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.wg.Wait()
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			if s.closed {
+				return nil
+			}
+			return err
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.listener == nil || s.closed {
+		return nil
+	}
+	s.closed = true
+	err := s.listener.Close()
+	os.Remove(s.socketPath)
+	return err
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(defaultTimeout))
+
+	var req request
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	switch req.Method {
+	case methodPublicKey:
+		resp.PublicKey = keypair.SerializePublicKey(s.signer.PublicKey())
+	case methodSign:
+		sig, err := s.signer.Sign(req.Data)
+		if err != nil {
+			resp.Error = fmt.Sprintf("sign error: %s", err.Error())
+		}
+		resp.Signature = sig
+	default:
+		resp.Error = fmt.Sprintf("unknown method %s", req.Method)
+	}
+	json.NewEncoder(conn).Encode(&resp)
+}
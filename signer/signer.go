@@ -0,0 +1,110 @@
+// Package signer keeps the private key of an account in a separate process.
+// A Server signs for one core.Signer on a Unix socket and a RemoteSigner is
+// the core.Signer used by the process that submits transactions.
+package signer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontology-crypto/keypair"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+const (
+	methodPublicKey = "publicKey"
+	methodSign      = "sign"
+
+	defaultTimeout = 10 * time.Second
+)
+
+// request and response are exchanged as one json object each, on a
+// connection used for a single request.
+type request struct {
+	Method string
+	Data   []byte
+}
+
+type response struct {
+	PublicKey []byte
+	Signature []byte
+	Error     string
+}
+
+// RemoteSigner is a core.Signer that has a Server sign for it.
+type RemoteSigner struct {
+	socketPath string
+	publicKey  keypair.PublicKey
+	address    ccom.Address
+	// Timeout bounds every request to the Server.
+	Timeout time.Duration
+}
+
+var _ core.Signer = (*RemoteSigner)(nil)
+
+// Dial connects to the Server listening on socketPath and fetches the public
+// key of its account.
+func Dial(socketPath string) (*RemoteSigner, error) {
+	s := &RemoteSigner{
+		socketPath: socketPath,
+		Timeout:    defaultTimeout,
+	}
+	resp, err := s.call(&request{Method: methodPublicKey})
+	if err != nil {
+		return nil, err
+	}
+	s.publicKey, err = keypair.DeserializePublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("signer public key error: %s", err.Error())
+	}
+	s.address = types.AddressFromPubKey(s.publicKey)
+	return s, nil
+}
+
+func (s *RemoteSigner) PublicKey() keypair.PublicKey {
+	return s.publicKey
+}
+
+func (s *RemoteSigner) Address() ccom.Address {
+	return s.address
+}
+
+func (s *RemoteSigner) Sign(data []byte) ([]byte, error) {
+	resp, err := s.call(&request{Method: methodSign, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+func (s *RemoteSigner) SignTransaction(tx *types.MutableTransaction) error {
+	return core.SignTransaction(s, tx)
+}
+
+func (s *RemoteSigner) call(req *request) (*response, error) {
+	conn, err := net.DialTimeout("unix", s.socketPath, s.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("signer dial error: %s", err.Error())
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(s.Timeout)); err != nil {
+		return nil, err
+	}
+
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("signer %s request error: %s", req.Method, err.Error())
+	}
+	var resp response
+	if err = json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("signer %s response error: %s", req.Method, err.Error())
+	}
+	if len(resp.Error) != 0 {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
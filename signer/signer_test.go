@@ -0,0 +1,108 @@
+package signer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontfs-contract-api/sim"
+	ont "github.com/ontio/ontology-go-sdk"
+)
+
+func startServer(t *testing.T, acc *ont.Account) string {
+	dir, err := os.MkdirTemp("", "signer")
+	if err != nil {
+		t.Fatalf("MkdirTemp error: %s", err.Error())
+	}
+	socketPath := filepath.Join(dir, "signer.sock")
+	server := NewServer(core.NewLocalSigner(acc))
+	if err = server.Listen(socketPath); err != nil {
+		t.Fatalf("Listen error: %s", err.Error())
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve()
+	}()
+	t.Cleanup(func() {
+		server.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve error: %s", err.Error())
+		}
+		os.RemoveAll(dir)
+	})
+	return socketPath
+}
+
+func TestRemoteSigner_Sign(t *testing.T) {
+	acc := ont.NewAccount()
+	remote, err := Dial(startServer(t, acc))
+	if err != nil {
+		t.Fatalf("Dial error: %s", err.Error())
+	}
+	if remote.Address() != acc.Address {
+		t.Fatalf("remote address %s, account address %s", remote.Address().ToBase58(), acc.Address.ToBase58())
+	}
+
+	data := []byte("remote signer")
+	sig, err := remote.Sign(data)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err.Error())
+	}
+	if err = common.Verify(acc.PublicKey, data, sig); err != nil {
+		t.Fatalf("Verify error: %s", err.Error())
+	}
+}
+
+func TestRemoteSigner_Core(t *testing.T) {
+	acc := ont.NewAccount()
+	remote, err := Dial(startServer(t, acc))
+	if err != nil {
+		t.Fatalf("Dial error: %s", err.Error())
+	}
+
+	cfg := sim.DefaultConfig()
+	cfg.GenesisTime = 1577836800
+	chain := sim.NewSimulator(cfg)
+	c := core.InitWithBackend(chain, nil, 0, 20000).WithSigner(remote)
+
+	if _, err = c.NodeRegister(1024*1024, 1577836800+100000, 600, "tcp://127.0.0.1:3389"); err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	if _, err = c.GetNodeInfo(acc.Address); err != nil {
+		t.Fatalf("GetNodeInfo error: %s", err.Error())
+	}
+	if _, err = c.GetFileList(); err != nil {
+		t.Fatalf("GetFileList with a remote passport error: %s", err.Error())
+	}
+}
+
+func TestServer_Listen(t *testing.T) {
+	dir := t.TempDir()
+	server := NewServer(core.NewLocalSigner(ont.NewAccount()))
+
+	filePath := filepath.Join(dir, "wallet.dat")
+	if err := os.WriteFile(filePath, []byte("wallet"), 0600); err != nil {
+		t.Fatalf("WriteFile error: %s", err.Error())
+	}
+	if err := server.Listen(filePath); err == nil {
+		t.Fatalf("Listen replaced a regular file")
+	}
+	if data, err := os.ReadFile(filePath); err != nil || string(data) != "wallet" {
+		t.Fatalf("regular file changed by Listen: %q %v", data, err)
+	}
+
+	socketPath := filepath.Join(dir, "signer.sock")
+	if err := server.Listen(socketPath); err != nil {
+		t.Fatalf("Listen error: %s", err.Error())
+	}
+	defer server.Close()
+	info, err := os.Lstat(socketPath)
+	if err != nil || info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected socket: %v %v", info, err)
+	}
+	if err = NewServer(core.NewLocalSigner(ont.NewAccount())).Listen(socketPath); err == nil {
+		t.Fatalf("Listen replaced a socket in use")
+	}
+}
@@ -0,0 +1,916 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// blockSizeKB is the size of one file block; volumes are counted in KB.
+const blockSizeKB = common.FILE_BLOCK_SIZE_KB
+
+var retTrue = []byte{1}
+
+type pledgeKey struct {
+	fileHash   string
+	downloader ccom.Address
+}
+
+type invokeCtx struct {
+	witnesses     []ccom.Address
+	height        uint64
+	timestamp     uint64
+	blockInterval uint64
+	global        fs.FsGlobalParam
+	notify        []interface{}
+}
+
+func (ctx *invokeCtx) checkWitness(addr ccom.Address) bool {
+	for _, witness := range ctx.witnesses {
+		if witness == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// heightAfter converts a duration in seconds to the block height reached
+// after that duration.
+func (ctx *invokeCtx) heightAfter(secs uint64) uint64 {
+	blocks := secs / ctx.blockInterval
+	if blocks == 0 {
+		blocks = 1
+	}
+	return ctx.height + blocks
+}
+
+type state struct {
+	nodes   map[ccom.Address]*fs.FsNodeInfo
+	files   map[string]*fs.FileInfo
+	pdps    map[string][]fs.PdpRecord
+	spaces  map[ccom.Address]*fs.SpaceInfo
+	pledges map[pledgeKey]*fs.ReadPledge
+}
+
+func newState() *state {
+	return &state{
+		nodes:   make(map[ccom.Address]*fs.FsNodeInfo),
+		files:   make(map[string]*fs.FileInfo),
+		pdps:    make(map[string][]fs.PdpRecord),
+		spaces:  make(map[ccom.Address]*fs.SpaceInfo),
+		pledges: make(map[pledgeKey]*fs.ReadPledge),
+	}
+}
+
+func (st *state) clone() *state {
+	n := newState()
+	for k, v := range st.nodes {
+		node := *v
+		n.nodes[k] = &node
+	}
+	for k, v := range st.files {
+		file := *v
+		n.files[k] = &file
+	}
+	for k, v := range st.pdps {
+		n.pdps[k] = append([]fs.PdpRecord(nil), v...)
+	}
+	for k, v := range st.spaces {
+		space := *v
+		n.spaces[k] = &space
+	}
+	for k, v := range st.pledges {
+		pledge := *v
+		pledge.ReadPlans = append([]fs.ReadPlan(nil), v.ReadPlans...)
+		n.pledges[k] = &pledge
+	}
+	return n
+}
+
+// expire drops files and spaces whose storage time has passed.
+func (st *state) expire(timestamp uint64) {
+	for hash, file := range st.files {
+		if file.TimeExpired < timestamp {
+			st.removeFile(hash)
+		}
+	}
+	for owner, space := range st.spaces {
+		if space.TimeExpired < timestamp {
+			delete(st.spaces, owner)
+		}
+	}
+}
+
+func (st *state) invoke(ctx *invokeCtx, method string, params []interface{}) ([]byte, error) {
+	switch method {
+	case fs.FS_GET_GLOBAL_PARAM:
+		return st.getGlobalParam(ctx)
+	case fs.FS_NODE_REGISTER:
+		return st.nodeRegister(ctx, params)
+	case fs.FS_NODE_QUERY:
+		return st.nodeQuery(params)
+	case fs.FS_NODE_UPDATE:
+		return st.nodeUpdate(ctx, params)
+	case fs.FS_NODE_CANCEL:
+		return st.nodeCancel(ctx, params)
+	case fs.FS_NODE_WITH_DRAW_PROFIT:
+		return st.nodeWithDrawProfit(ctx, params)
+	case fs.FS_GET_NODE_LIST:
+		return st.getNodeInfoList(params)
+	case fs.FS_FILE_PROVE:
+		return st.fileProve(ctx, params)
+	case fs.FS_GET_PDP_INFO_LIST:
+		return st.getPdpInfoList(params)
+	case fs.FS_CREATE_SPACE:
+		return st.createSpace(ctx, params)
+	case fs.FS_GET_SPACE_INFO:
+		return st.getSpaceInfo(params)
+	case fs.FS_UPDATE_SPACE:
+		return st.updateSpace(ctx, params)
+	case fs.FS_DELETE_SPACE:
+		return st.deleteSpace(ctx, params)
+	case fs.FS_STORE_FILES:
+		return st.storeFiles(ctx, params)
+	case fs.FS_RENEW_FILES:
+		return st.renewFiles(ctx, params)
+	case fs.FS_DELETE_FILES:
+		return st.deleteFiles(ctx, params)
+	case fs.FS_TRANSFER_FILES:
+		return st.transferFiles(ctx, params)
+	case fs.FS_GET_FILE_INFO:
+		return st.getFileInfo(params)
+	case fs.FS_GET_FILE_LIST:
+		return st.getFileList(ctx, params)
+	case fs.FS_READ_FILE_PLEDGE:
+		return st.readFilePledge(ctx, params)
+	case fs.FS_GET_READ_PLEDGE:
+		return st.getReadPledge(params)
+	case fs.FS_READ_FILE_SETTLE:
+		return st.readFileSettle(ctx, params)
+	case fs.FS_CANCEL_FILE_READ:
+		return st.cancelFileRead(ctx, params)
+	default:
+		return nil, fmt.Errorf("unknown method %s", method)
+	}
+}
+
+func (st *state) getGlobalParam(ctx *invokeCtx) ([]byte, error) {
+	sink := ccom.NewZeroCopySink(nil)
+	ctx.global.Serialization(sink)
+	return fs.EncRet(true, sink.Bytes()), nil
+}
+
+func (st *state) nodeRegister(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	nodeInfo, err := paramNodeInfo(params)
+	if err != nil {
+		return nil, err
+	}
+	if !ctx.checkWitness(nodeInfo.NodeAddr) {
+		return nil, errors.New("FsNodeRegister CheckWitness failed")
+	}
+	if _, ok := st.nodes[nodeInfo.NodeAddr]; ok {
+		return nil, errors.New("FsNodeRegister node has registered")
+	}
+	if nodeInfo.Volume < ctx.global.NodeMinVolume {
+		return nil, errors.New("FsNodeRegister volume is less than NodeMinVolume")
+	}
+	if nodeInfo.ServiceTime <= ctx.timestamp {
+		return nil, errors.New("FsNodeRegister serviceTime is expired")
+	}
+
+	node := fs.FsNodeInfo{
+		Pledge:         nodeInfo.Volume * ctx.global.NodePerKbPledge,
+		Volume:         nodeInfo.Volume,
+		RestVol:        nodeInfo.Volume,
+		ServiceTime:    nodeInfo.ServiceTime,
+		MinPdpInterval: nodeInfo.MinPdpInterval,
+		NodeAddr:       nodeInfo.NodeAddr,
+		NodeNetAddr:    nodeInfo.NodeNetAddr,
+	}
+	st.nodes[node.NodeAddr] = &node
+	return retTrue, nil
+}
+
+func (st *state) nodeQuery(params []interface{}) ([]byte, error) {
+	addr, err := paramAddress(params)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := st.nodes[addr]
+	if !ok {
+		return fs.EncRet(false, []byte("FsNodeQuery node not found")), nil
+	}
+	sink := ccom.NewZeroCopySink(nil)
+	node.Serialization(sink)
+	return fs.EncRet(true, sink.Bytes()), nil
+}
+
+func (st *state) nodeUpdate(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	nodeInfo, err := paramNodeInfo(params)
+	if err != nil {
+		return nil, err
+	}
+	if !ctx.checkWitness(nodeInfo.NodeAddr) {
+		return nil, errors.New("FsNodeUpdate CheckWitness failed")
+	}
+	node, ok := st.nodes[nodeInfo.NodeAddr]
+	if !ok {
+		return nil, errors.New("FsNodeUpdate node not found")
+	}
+	used := node.Volume - node.RestVol
+	if nodeInfo.Volume < used || nodeInfo.Volume < ctx.global.NodeMinVolume {
+		return nil, errors.New("FsNodeUpdate volume is too small")
+	}
+	if nodeInfo.ServiceTime <= ctx.timestamp {
+		return nil, errors.New("FsNodeUpdate serviceTime is expired")
+	}
+
+	node.Pledge = nodeInfo.Volume * ctx.global.NodePerKbPledge
+	node.Volume = nodeInfo.Volume
+	node.RestVol = nodeInfo.Volume - used
+	node.ServiceTime = nodeInfo.ServiceTime
+	node.MinPdpInterval = nodeInfo.MinPdpInterval
+	node.NodeNetAddr = nodeInfo.NodeNetAddr
+	return retTrue, nil
+}
+
+func (st *state) nodeCancel(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	addr, err := paramAddress(params)
+	if err != nil {
+		return nil, err
+	}
+	if !ctx.checkWitness(addr) {
+		return nil, errors.New("FsNodeCancel CheckWitness failed")
+	}
+	node, ok := st.nodes[addr]
+	if !ok {
+		return nil, errors.New("FsNodeCancel node not found")
+	}
+	if node.RestVol != node.Volume {
+		return nil, errors.New("FsNodeCancel node still stores files")
+	}
+	delete(st.nodes, addr)
+	return retTrue, nil
+}
+
+func (st *state) nodeWithDrawProfit(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	addr, err := paramAddress(params)
+	if err != nil {
+		return nil, err
+	}
+	if !ctx.checkWitness(addr) {
+		return nil, errors.New("FsNodeWithDrawProfit CheckWitness failed")
+	}
+	node, ok := st.nodes[addr]
+	if !ok {
+		return nil, errors.New("FsNodeWithDrawProfit node not found")
+	}
+	if node.Profit == 0 {
+		return nil, errors.New("FsNodeWithDrawProfit profit is zero")
+	}
+	node.Profit = 0
+	return retTrue, nil
+}
+
+func (st *state) getNodeInfoList(params []interface{}) ([]byte, error) {
+	count, err := paramUint64(params)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]ccom.Address, 0, len(st.nodes))
+	for addr := range st.nodes {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].ToHexString() < addrs[j].ToHexString()
+	})
+
+	var nodeList fs.FsNodeInfoList
+	for _, addr := range addrs {
+		if uint64(len(nodeList.NodesInfo)) >= count {
+			break
+		}
+		nodeList.NodesInfo = append(nodeList.NodesInfo, *st.nodes[addr])
+	}
+	sink := ccom.NewZeroCopySink(nil)
+	nodeList.Serialization(sink)
+	return fs.EncRet(true, sink.Bytes()), nil
+}
+
+func (st *state) fileProve(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	pdpData, err := paramPdpData(params)
+	if err != nil {
+		return nil, err
+	}
+	if !ctx.checkWitness(pdpData.NodeAddr) {
+		return nil, errors.New("FsFileProve CheckWitness failed")
+	}
+	if len(pdpData.ProveData) == 0 {
+		return nil, errors.New("FsFileProve proveData is empty")
+	}
+	node, ok := st.nodes[pdpData.NodeAddr]
+	if !ok {
+		return nil, errors.New("FsFileProve node not found")
+	}
+	fileHash := string(pdpData.FileHash)
+	fileInfo, ok := st.files[fileHash]
+	if !ok {
+		return nil, errors.New("FsFileProve file not found")
+	}
+	if pdpData.ChallengeHeight > ctx.height {
+		return nil, errors.New("FsFileProve challengeHeight is in the future")
+	}
+
+	records := st.pdps[fileHash]
+	index := -1
+	for i := range records {
+		if records[i].NodeAddr == pdpData.NodeAddr {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		if uint64(len(records)) >= fileInfo.CopyNumber {
+			return nil, errors.New("FsFileProve file has enough copies")
+		}
+		if fileInfo.PdpInterval < node.MinPdpInterval {
+			return nil, errors.New("FsFileProve pdpInterval is less than node MinPdpInterval")
+		}
+		if node.ServiceTime < fileInfo.TimeExpired {
+			return nil, errors.New("FsFileProve node serviceTime is less than file timeExpired")
+		}
+		fileSize := fileInfo.FileBlockCount * blockSizeKB
+		if node.RestVol < fileSize {
+			return nil, errors.New("FsFileProve node has no enough volume")
+		}
+		node.RestVol -= fileSize
+		records = append(records, fs.PdpRecord{
+			NodeAddr:  pdpData.NodeAddr,
+			FileHash:  pdpData.FileHash,
+			FileOwner: fileInfo.FileOwner,
+		})
+		index = len(records) - 1
+	} else if pdpData.ChallengeHeight != records[index].NextHeight {
+		return nil, fmt.Errorf("FsFileProve challengeHeight should be %d", records[index].NextHeight)
+	}
+
+	record := &records[index]
+	record.PdpCount++
+	record.LastPdpHeight = ctx.height
+	record.NextHeight = ctx.heightAfter(fileInfo.PdpInterval)
+	st.pdps[fileHash] = records
+
+	reward := fileInfo.PayAmount / (fileInfo.CopyNumber * core.PdpCount(fileInfo.TimeStart,
+		fileInfo.TimeExpired, fileInfo.PdpInterval))
+	if reward > fileInfo.RestAmount {
+		reward = fileInfo.RestAmount
+	}
+	fileInfo.RestAmount -= reward
+	node.Profit += reward
+	return retTrue, nil
+}
+
+func (st *state) getPdpInfoList(params []interface{}) ([]byte, error) {
+	fileHash, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := st.files[string(fileHash)]; !ok {
+		return fs.EncRet(false, []byte("FsGetPdpInfoList file not found")), nil
+	}
+	pdpRecordList := fs.PdpRecordList{PdpRecords: st.pdps[string(fileHash)]}
+	sink := ccom.NewZeroCopySink(nil)
+	pdpRecordList.Serialization(sink)
+	return fs.EncRet(true, sink.Bytes()), nil
+}
+
+func (st *state) createSpace(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	data, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	var spaceInfo fs.SpaceInfo
+	if err = spaceInfo.Deserialization(ccom.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("FsCreateSpace deserialize error: %s", err.Error())
+	}
+	if !ctx.checkWitness(spaceInfo.SpaceOwner) {
+		return nil, errors.New("FsCreateSpace CheckWitness failed")
+	}
+	if _, ok := st.spaces[spaceInfo.SpaceOwner]; ok {
+		return nil, errors.New("FsCreateSpace space has existed")
+	}
+	if spaceInfo.Volume == 0 || spaceInfo.CopyNumber == 0 {
+		return nil, errors.New("FsCreateSpace volume or copyNumber is zero")
+	}
+	if spaceInfo.TimeExpired < ctx.timestamp+ctx.global.MinTimeForFileStorage {
+		return nil, errors.New("FsCreateSpace timeExpired is too early")
+	}
+
+	space := fs.SpaceInfo{
+		SpaceOwner:  spaceInfo.SpaceOwner,
+		Volume:      spaceInfo.Volume,
+		RestVol:     spaceInfo.Volume,
+		CopyNumber:  spaceInfo.CopyNumber,
+		PdpInterval: spaceInfo.PdpInterval,
+		TimeStart:   ctx.timestamp,
+		TimeExpired: spaceInfo.TimeExpired,
+		CurrFeeRate: ctx.global.SpacePerBlockFeeRate,
+		ValidFlag:   true,
+	}
+	space.PayAmount = core.SpaceFee(&ctx.global, space.Volume, space.CopyNumber, space.PdpInterval,
+		space.TimeStart, space.TimeExpired).Total()
+	space.RestAmount = space.PayAmount
+	st.spaces[space.SpaceOwner] = &space
+	return retTrue, nil
+}
+
+func (st *state) getSpaceInfo(params []interface{}) ([]byte, error) {
+	addr, err := paramAddress(params)
+	if err != nil {
+		return nil, err
+	}
+	space, ok := st.spaces[addr]
+	if !ok {
+		return fs.EncRet(false, []byte("FsGetSpaceInfo space not found")), nil
+	}
+	sink := ccom.NewZeroCopySink(nil)
+	space.Serialization(sink)
+	return fs.EncRet(true, sink.Bytes()), nil
+}
+
+func (st *state) updateSpace(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	data, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	var spaceUpdate fs.SpaceUpdate
+	if err = spaceUpdate.Deserialization(ccom.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("FsUpdateSpace deserialize error: %s", err.Error())
+	}
+	if !ctx.checkWitness(spaceUpdate.SpaceOwner) && !ctx.checkWitness(spaceUpdate.Payer) {
+		return nil, errors.New("FsUpdateSpace CheckWitness failed")
+	}
+	space, ok := st.spaces[spaceUpdate.SpaceOwner]
+	if !ok {
+		return nil, errors.New("FsUpdateSpace space not found")
+	}
+	used := space.Volume - space.RestVol
+	if spaceUpdate.NewVolume < used {
+		return nil, errors.New("FsUpdateSpace newVolume is less than used volume")
+	}
+	if spaceUpdate.NewTimeExpired < space.TimeExpired {
+		return nil, errors.New("FsUpdateSpace newTimeExpired is earlier than timeExpired")
+	}
+
+	newFee := core.SpaceFee(&ctx.global, spaceUpdate.NewVolume, space.CopyNumber, space.PdpInterval,
+		space.TimeStart, spaceUpdate.NewTimeExpired).Total()
+	if newFee > space.PayAmount {
+		space.RestAmount += newFee - space.PayAmount
+		space.PayAmount = newFee
+	}
+	space.Volume = spaceUpdate.NewVolume
+	space.RestVol = spaceUpdate.NewVolume - used
+	space.TimeExpired = spaceUpdate.NewTimeExpired
+	return retTrue, nil
+}
+
+func (st *state) deleteSpace(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	addr, err := paramAddress(params)
+	if err != nil {
+		return nil, err
+	}
+	if !ctx.checkWitness(addr) {
+		return nil, errors.New("FsDeleteSpace CheckWitness failed")
+	}
+	space, ok := st.spaces[addr]
+	if !ok {
+		return nil, errors.New("FsDeleteSpace space not found")
+	}
+	if space.RestVol != space.Volume {
+		return nil, errors.New("FsDeleteSpace space still stores files")
+	}
+	delete(st.spaces, addr)
+	return retTrue, nil
+}
+
+func (st *state) storeFiles(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	data, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	var fileInfoList fs.FileInfoList
+	if err = fileInfoList.Deserialization(ccom.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("FsStoreFiles deserialize error: %s", err.Error())
+	}
+
+	var errInfos fs.Errors
+	for _, fileInfo := range fileInfoList.FilesI {
+		fileHash := string(fileInfo.FileHash)
+		if !ctx.checkWitness(fileInfo.FileOwner) {
+			errInfos.AddObjectError(fileHash, "FsStoreFiles CheckWitness failed")
+			continue
+		}
+		if _, ok := st.files[fileHash]; ok {
+			errInfos.AddObjectError(fileHash, "FsStoreFiles file has stored")
+			continue
+		}
+		if fileInfo.FileBlockCount == 0 {
+			errInfos.AddObjectError(fileHash, "FsStoreFiles fileBlockCount is zero")
+			continue
+		}
+
+		file := fileInfo
+		file.TimeStart = ctx.timestamp
+		file.BeginHeight = ctx.height
+		file.ValidFlag = true
+		if fileInfo.StorageType == fs.FileStorageTypeUseSpace {
+			space, ok := st.spaces[fileInfo.FileOwner]
+			if !ok {
+				errInfos.AddObjectError(fileHash, "FsStoreFiles space not found")
+				continue
+			}
+			fileSize := fileInfo.FileBlockCount * blockSizeKB
+			if space.RestVol < fileSize {
+				errInfos.AddObjectError(fileHash, "FsStoreFiles space has no enough volume")
+				continue
+			}
+			space.RestVol -= fileSize
+			file.CopyNumber = space.CopyNumber
+			file.PdpInterval = space.PdpInterval
+			file.TimeExpired = space.TimeExpired
+		} else {
+			if file.CopyNumber == 0 {
+				errInfos.AddObjectError(fileHash, "FsStoreFiles copyNumber is zero")
+				continue
+			}
+			if file.TimeExpired < ctx.timestamp+ctx.global.MinTimeForFileStorage {
+				errInfos.AddObjectError(fileHash, "FsStoreFiles timeExpired is too early")
+				continue
+			}
+			file.CurrFeeRate = ctx.global.FilePerBlockFeeRate
+			file.PayAmount = core.FileFee(&ctx.global, file.FileBlockCount, file.CopyNumber, file.PdpInterval,
+				file.TimeStart, file.TimeExpired).Total()
+			file.RestAmount = file.PayAmount
+		}
+		file.ExpiredHeight = ctx.heightAfter(file.TimeExpired - ctx.timestamp)
+		st.files[fileHash] = &file
+	}
+	ctx.notify = append(ctx.notify, errInfos.ToString())
+	return retTrue, nil
+}
+
+func (st *state) renewFiles(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	data, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	var fileReNewList fs.FileReNewList
+	if err = fileReNewList.Deserialization(ccom.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("FsRenewFiles deserialize error: %s", err.Error())
+	}
+
+	var errInfos fs.Errors
+	for _, fileReNew := range fileReNewList.FilesReNew {
+		fileHash := string(fileReNew.FileHash)
+		if !ctx.checkWitness(fileReNew.Payer) {
+			errInfos.AddObjectError(fileHash, "FsRenewFiles CheckWitness failed")
+			continue
+		}
+		file, ok := st.files[fileHash]
+		if !ok {
+			errInfos.AddObjectError(fileHash, "FsRenewFiles file not found")
+			continue
+		}
+		if file.FileOwner != fileReNew.FileOwner {
+			errInfos.AddObjectError(fileHash, "FsRenewFiles fileOwner is wrong")
+			continue
+		}
+		if file.StorageType == fs.FileStorageTypeUseSpace {
+			errInfos.AddObjectError(fileHash, "FsRenewFiles file is stored in space")
+			continue
+		}
+		if fileReNew.NewTimeExpired <= file.TimeExpired {
+			errInfos.AddObjectError(fileHash, "FsRenewFiles newTimeExpired is not later than timeExpired")
+			continue
+		}
+		newFee := core.FileFee(&ctx.global, file.FileBlockCount, file.CopyNumber, file.PdpInterval,
+			file.TimeStart, fileReNew.NewTimeExpired).Total()
+		if newFee > file.PayAmount {
+			file.RestAmount += newFee - file.PayAmount
+			file.PayAmount = newFee
+		}
+		file.TimeExpired = fileReNew.NewTimeExpired
+		file.ExpiredHeight = ctx.heightAfter(file.TimeExpired - ctx.timestamp)
+	}
+	ctx.notify = append(ctx.notify, errInfos.ToString())
+	return retTrue, nil
+}
+
+func (st *state) deleteFiles(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	data, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	var fileDelList fs.FileDelList
+	if err = fileDelList.Deserialization(ccom.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("FsDeleteFiles deserialize error: %s", err.Error())
+	}
+
+	var errInfos fs.Errors
+	for _, fileDel := range fileDelList.FilesDel {
+		fileHash := string(fileDel.FileHash)
+		file, ok := st.files[fileHash]
+		if !ok {
+			errInfos.AddObjectError(fileHash, "FsDeleteFiles file not found")
+			continue
+		}
+		if !ctx.checkWitness(file.FileOwner) {
+			errInfos.AddObjectError(fileHash, "FsDeleteFiles CheckWitness failed")
+			continue
+		}
+		st.removeFile(fileHash)
+	}
+	ctx.notify = append(ctx.notify, errInfos.ToString())
+	return retTrue, nil
+}
+
+func (st *state) transferFiles(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	data, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	var fileTransferList fs.FileTransferList
+	if err = fileTransferList.Deserialization(ccom.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("FsTransferFiles deserialize error: %s", err.Error())
+	}
+
+	var errInfos fs.Errors
+	for _, fileTransfer := range fileTransferList.FilesTransfer {
+		fileHash := string(fileTransfer.FileHash)
+		if !ctx.checkWitness(fileTransfer.OriOwner) {
+			errInfos.AddObjectError(fileHash, "FsTransferFiles CheckWitness failed")
+			continue
+		}
+		file, ok := st.files[fileHash]
+		if !ok {
+			errInfos.AddObjectError(fileHash, "FsTransferFiles file not found")
+			continue
+		}
+		if file.FileOwner != fileTransfer.OriOwner {
+			errInfos.AddObjectError(fileHash, "FsTransferFiles oriOwner is wrong")
+			continue
+		}
+		if file.StorageType == fs.FileStorageTypeUseSpace {
+			errInfos.AddObjectError(fileHash, "FsTransferFiles file is stored in space")
+			continue
+		}
+		file.FileOwner = fileTransfer.NewOwner
+		records := st.pdps[fileHash]
+		for i := range records {
+			records[i].FileOwner = fileTransfer.NewOwner
+		}
+	}
+	ctx.notify = append(ctx.notify, errInfos.ToString())
+	return retTrue, nil
+}
+
+func (st *state) getFileInfo(params []interface{}) ([]byte, error) {
+	fileHash, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	file, ok := st.files[string(fileHash)]
+	if !ok {
+		return fs.EncRet(false, []byte("FsGetFileInfo file not found")), nil
+	}
+	sink := ccom.NewZeroCopySink(nil)
+	file.Serialization(sink)
+	return fs.EncRet(true, sink.Bytes()), nil
+}
+
+func (st *state) getFileList(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	passport, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := fs.CheckPassport(ctx.height, passport)
+	if err != nil {
+		return fs.EncRet(false, []byte("FsGetFileList CheckPassport error: "+err.Error())), nil
+	}
+
+	var fileList fs.FileHashList
+	for hash, file := range st.files {
+		if file.FileOwner == owner {
+			fileList.FilesH = append(fileList.FilesH, fs.FileHash{FHash: []byte(hash)})
+		}
+	}
+	sort.Slice(fileList.FilesH, func(i, j int) bool {
+		return string(fileList.FilesH[i].FHash) < string(fileList.FilesH[j].FHash)
+	})
+	sink := ccom.NewZeroCopySink(nil)
+	fileList.Serialization(sink)
+	return fs.EncRet(true, sink.Bytes()), nil
+}
+
+func (st *state) readFilePledge(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	data, err := paramBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	var readPledge fs.ReadPledge
+	if err = readPledge.Deserialization(ccom.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("FsReadFilePledge deserialize error: %s", err.Error())
+	}
+	if !ctx.checkWitness(readPledge.Downloader) {
+		return nil, errors.New("FsReadFilePledge CheckWitness failed")
+	}
+	fileHash := string(readPledge.FileHash)
+	file, ok := st.files[fileHash]
+	if !ok {
+		return nil, errors.New("FsReadFilePledge file not found")
+	}
+	if len(readPledge.ReadPlans) == 0 {
+		return nil, errors.New("FsReadFilePledge readPlans is empty")
+	}
+
+	key := pledgeKey{fileHash: fileHash, downloader: readPledge.Downloader}
+	pledge, ok := st.pledges[key]
+	if !ok {
+		pledge = &fs.ReadPledge{
+			FileHash:     readPledge.FileHash,
+			Downloader:   readPledge.Downloader,
+			BlockHeight:  ctx.height,
+			ExpireHeight: file.ExpiredHeight,
+		}
+	}
+	for _, plan := range readPledge.ReadPlans {
+		if !st.nodeStoresFile(plan.NodeAddr, fileHash) {
+			return nil, fmt.Errorf("FsReadFilePledge node %s does not store the file", plan.NodeAddr.ToBase58())
+		}
+		if plan.MaxReadBlockNum == 0 || plan.MaxReadBlockNum > file.FileBlockCount {
+			return nil, errors.New("FsReadFilePledge maxReadBlockNum is invalid")
+		}
+		merged := false
+		for i := range pledge.ReadPlans {
+			if pledge.ReadPlans[i].NodeAddr == plan.NodeAddr {
+				pledge.ReadPlans[i].MaxReadBlockNum += plan.MaxReadBlockNum
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			pledge.ReadPlans = append(pledge.ReadPlans, fs.ReadPlan{
+				NodeAddr:        plan.NodeAddr,
+				MaxReadBlockNum: plan.MaxReadBlockNum,
+			})
+		}
+		pledge.RestMoney += plan.MaxReadBlockNum * ctx.global.FeePerBlockForRead
+	}
+	st.pledges[key] = pledge
+	return retTrue, nil
+}
+
+func (st *state) getReadPledge(params []interface{}) ([]byte, error) {
+	getReadPledge, err := paramGetReadPledge(params)
+	if err != nil {
+		return nil, err
+	}
+	pledge, ok := st.pledges[pledgeKey{fileHash: string(getReadPledge.FileHash), downloader: getReadPledge.Downloader}]
+	if !ok {
+		return fs.EncRet(false, []byte("FsGetReadPledge readPledge not found")), nil
+	}
+	sink := ccom.NewZeroCopySink(nil)
+	pledge.Serialization(sink)
+	return fs.EncRet(true, sink.Bytes()), nil
+}
+
+func (st *state) readFileSettle(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	settleSlice, err := paramSettleSlice(params)
+	if err != nil {
+		return nil, err
+	}
+	if !ctx.checkWitness(settleSlice.PayTo) {
+		return nil, errors.New("FsReadFileSettle CheckWitness failed")
+	}
+	if err = verifySettleSlice(settleSlice); err != nil {
+		return nil, err
+	}
+	key := pledgeKey{fileHash: string(settleSlice.FileHash), downloader: settleSlice.PayFrom}
+	pledge, ok := st.pledges[key]
+	if !ok {
+		return nil, errors.New("FsReadFileSettle readPledge not found")
+	}
+	if ctx.height > pledge.ExpireHeight {
+		return nil, errors.New("FsReadFileSettle readPledge is expired")
+	}
+	node, ok := st.nodes[settleSlice.PayTo]
+	if !ok {
+		return nil, errors.New("FsReadFileSettle node not found")
+	}
+
+	for i := range pledge.ReadPlans {
+		plan := &pledge.ReadPlans[i]
+		if plan.NodeAddr != settleSlice.PayTo {
+			continue
+		}
+		if settleSlice.SliceId > plan.MaxReadBlockNum || settleSlice.SliceId <= plan.HaveReadBlockNum {
+			return nil, errors.New("FsReadFileSettle sliceId is invalid")
+		}
+		profit := (settleSlice.SliceId - plan.HaveReadBlockNum) * ctx.global.FeePerBlockForRead
+		if profit > pledge.RestMoney {
+			profit = pledge.RestMoney
+		}
+		plan.HaveReadBlockNum = settleSlice.SliceId
+		pledge.RestMoney -= profit
+		node.Profit += profit
+		return retTrue, nil
+	}
+	return nil, errors.New("FsReadFileSettle readPlan not found")
+}
+
+func (st *state) cancelFileRead(ctx *invokeCtx, params []interface{}) ([]byte, error) {
+	getReadPledge, err := paramGetReadPledge(params)
+	if err != nil {
+		return nil, err
+	}
+	if !ctx.checkWitness(getReadPledge.Downloader) {
+		return nil, errors.New("FsCancelFileRead CheckWitness failed")
+	}
+	key := pledgeKey{fileHash: string(getReadPledge.FileHash), downloader: getReadPledge.Downloader}
+	if _, ok := st.pledges[key]; !ok {
+		return nil, errors.New("FsCancelFileRead readPledge not found")
+	}
+	delete(st.pledges, key)
+	return retTrue, nil
+}
+
+func (st *state) nodeStoresFile(nodeAddr ccom.Address, fileHash string) bool {
+	for _, record := range st.pdps[fileHash] {
+		if record.NodeAddr == nodeAddr {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *state) removeFile(fileHash string) {
+	file, ok := st.files[fileHash]
+	if !ok {
+		return
+	}
+	fileSize := file.FileBlockCount * blockSizeKB
+	for _, record := range st.pdps[fileHash] {
+		if node, ok := st.nodes[record.NodeAddr]; ok {
+			node.RestVol += fileSize
+		}
+	}
+	if file.StorageType == fs.FileStorageTypeUseSpace {
+		if space, ok := st.spaces[file.FileOwner]; ok {
+			space.RestVol += fileSize
+		}
+	}
+	delete(st.pdps, fileHash)
+	delete(st.files, fileHash)
+	for key := range st.pledges {
+		if key.fileHash == fileHash {
+			delete(st.pledges, key)
+		}
+	}
+}
+
+func verifySettleSlice(settleSlice *fs.FileReadSettleSlice) error {
+	tmpSettleSlice := fs.FileReadSettleSlice{
+		FileHash:     settleSlice.FileHash,
+		PayFrom:      settleSlice.PayFrom,
+		PayTo:        settleSlice.PayTo,
+		SliceId:      settleSlice.SliceId,
+		PledgeHeight: settleSlice.PledgeHeight,
+	}
+	sink := ccom.NewZeroCopySink(nil)
+	tmpSettleSlice.Serialization(sink)
+
+	sig, err := signature.Deserialize(settleSlice.Sig)
+	if err != nil {
+		return fmt.Errorf("FsReadFileSettle signature deserialize error: %s", err.Error())
+	}
+	pubKey, err := keypair.DeserializePublicKey(settleSlice.PubKey)
+	if err != nil {
+		return fmt.Errorf("FsReadFileSettle publicKey deserialize error: %s", err.Error())
+	}
+	if types.AddressFromPubKey(pubKey) != settleSlice.PayFrom {
+		return errors.New("FsReadFileSettle publicKey does not match payFrom")
+	}
+	if !signature.Verify(pubKey, sink.Bytes(), sig) {
+		return errors.New("FsReadFileSettle signature verify failed")
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+
+	ccom "github.com/ontio/ontology/common"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+// The helpers below read the first invocation parameter in the forms that
+// core.Core passes to InvokeNativeContract and PreExecInvokeNativeContract.
+
+func firstParam(params []interface{}) (interface{}, error) {
+	if len(params) == 0 {
+		return nil, errors.New("params is empty")
+	}
+	return params[0], nil
+}
+
+func paramAddress(params []interface{}) (ccom.Address, error) {
+	param, err := firstParam(params)
+	if err != nil {
+		return ccom.ADDRESS_EMPTY, err
+	}
+	switch v := param.(type) {
+	case ccom.Address:
+		return v, nil
+	case *ccom.Address:
+		return *v, nil
+	case []byte:
+		return ccom.AddressParseFromBytes(v)
+	default:
+		return ccom.ADDRESS_EMPTY, fmt.Errorf("param type %T is not an address", param)
+	}
+}
+
+func paramBytes(params []interface{}) ([]byte, error) {
+	param, err := firstParam(params)
+	if err != nil {
+		return nil, err
+	}
+	switch v := param.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("param type %T is not a byte array", param)
+	}
+}
+
+func paramUint64(params []interface{}) (uint64, error) {
+	param, err := firstParam(params)
+	if err != nil {
+		return 0, err
+	}
+	switch v := param.(type) {
+	case uint64:
+		return v, nil
+	case int:
+		return uint64(v), nil
+	case uint32:
+		return uint64(v), nil
+	default:
+		return 0, fmt.Errorf("param type %T is not an integer", param)
+	}
+}
+
+func paramNodeInfo(params []interface{}) (*fs.FsNodeInfo, error) {
+	param, err := firstParam(params)
+	if err != nil {
+		return nil, err
+	}
+	switch v := param.(type) {
+	case *fs.FsNodeInfo:
+		return v, nil
+	case fs.FsNodeInfo:
+		return &v, nil
+	case []byte:
+		var nodeInfo fs.FsNodeInfo
+		if err = nodeInfo.Deserialization(ccom.NewZeroCopySource(v)); err != nil {
+			return nil, err
+		}
+		return &nodeInfo, nil
+	default:
+		return nil, fmt.Errorf("param type %T is not FsNodeInfo", param)
+	}
+}
+
+func paramPdpData(params []interface{}) (*fs.PdpData, error) {
+	param, err := firstParam(params)
+	if err != nil {
+		return nil, err
+	}
+	switch v := param.(type) {
+	case *fs.PdpData:
+		return v, nil
+	case fs.PdpData:
+		return &v, nil
+	case []byte:
+		var pdpData fs.PdpData
+		if err = pdpData.Deserialization(ccom.NewZeroCopySource(v)); err != nil {
+			return nil, err
+		}
+		return &pdpData, nil
+	default:
+		return nil, fmt.Errorf("param type %T is not PdpData", param)
+	}
+}
+
+func paramGetReadPledge(params []interface{}) (*fs.GetReadPledge, error) {
+	param, err := firstParam(params)
+	if err != nil {
+		return nil, err
+	}
+	switch v := param.(type) {
+	case *fs.GetReadPledge:
+		return v, nil
+	case fs.GetReadPledge:
+		return &v, nil
+	case []byte:
+		var getReadPledge fs.GetReadPledge
+		if err = getReadPledge.Deserialization(ccom.NewZeroCopySource(v)); err != nil {
+			return nil, err
+		}
+		return &getReadPledge, nil
+	default:
+		return nil, fmt.Errorf("param type %T is not GetReadPledge", param)
+	}
+}
+
+func paramSettleSlice(params []interface{}) (*fs.FileReadSettleSlice, error) {
+	param, err := firstParam(params)
+	if err != nil {
+		return nil, err
+	}
+	switch v := param.(type) {
+	case *fs.FileReadSettleSlice:
+		return v, nil
+	case fs.FileReadSettleSlice:
+		return &v, nil
+	case []byte:
+		var settleSlice fs.FileReadSettleSlice
+		if err = settleSlice.Deserialization(ccom.NewZeroCopySource(v)); err != nil {
+			return nil, err
+		}
+		return &settleSlice, nil
+	default:
+		return nil, fmt.Errorf("param type %T is not FileReadSettleSlice", param)
+	}
+}
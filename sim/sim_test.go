@@ -0,0 +1,140 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
+	ont "github.com/ontio/ontology-go-sdk"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+)
+
+const testGenesisTime = 1577836800
+
+func newTestChain(t *testing.T) (*Simulator, *core.Core, *core.Core) {
+	cfg := DefaultConfig()
+	cfg.GenesisTime = testGenesisTime
+	chain := NewSimulator(cfg)
+
+	node := core.InitWithBackend(chain, ont.NewAccount(), 0, 20000)
+	client := core.InitWithBackend(chain, ont.NewAccount(), 0, 20000)
+	if node == nil || client == nil {
+		t.Fatalf("InitWithBackend error")
+	}
+	return chain, node, client
+}
+
+func TestSimulator_GlobalParam(t *testing.T) {
+	chain, _, client := newTestChain(t)
+	globalParam, err := client.GetGlobalParam()
+	if err != nil {
+		t.Fatalf("GetGlobalParam error: %s", err.Error())
+	}
+	if *globalParam != chain.cfg.GlobalParam {
+		t.Fatalf("GetGlobalParam got %+v", *globalParam)
+	}
+}
+
+func TestSimulator_StoreProveExpire(t *testing.T) {
+	chain, node, client := newTestChain(t)
+
+	_, err := node.NodeRegister(1024*1024*1024, testGenesisTime+100000, 600, "tcp://127.0.0.1:3389")
+	if err != nil {
+		t.Fatalf("NodeRegister error: %s", err.Error())
+	}
+	nodeInfo, err := client.GetNodeInfo(node.WalletAddr)
+	if err != nil {
+		t.Fatalf("GetNodeInfo error: %s", err.Error())
+	}
+	if nodeInfo.RestVol != nodeInfo.Volume {
+		t.Fatalf("RestVol %d != Volume %d", nodeInfo.RestVol, nodeInfo.Volume)
+	}
+
+	fileStores := []common.FileStore{
+		{
+			FileHash:       "SimFile",
+			FileBlockCount: 4,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			TimeExpired:    testGenesisTime + 3*3600,
+			StorageType:    fs.FileStorageTypeUseFile,
+		},
+		{
+			FileHash:       "SimFileTooShort",
+			FileBlockCount: 4,
+			CopyNumber:     1,
+			PdpInterval:    600,
+			TimeExpired:    testGenesisTime + 60,
+			StorageType:    fs.FileStorageTypeUseFile,
+		},
+	}
+	_, err, storeErrors := client.StoreFiles(fileStores)
+	if err != nil {
+		t.Fatalf("StoreFiles error: %s", err.Error())
+	}
+	if len(storeErrors.ObjectErrors) != 1 || storeErrors.ObjectErrors["SimFileTooShort"] == "" {
+		t.Fatalf("StoreFiles errors: %v", storeErrors.ObjectErrors)
+	}
+
+	if _, err = node.FileProve("SimFile", []byte("prove"), uint64(chain.Height())); err != nil {
+		t.Fatalf("FileProve error: %s", err.Error())
+	}
+	pdpRecordList, err := client.GetFilePdpRecordList("SimFile")
+	if err != nil {
+		t.Fatalf("GetFilePdpRecordList error: %s", err.Error())
+	}
+	if len(pdpRecordList.PdpRecords) != 1 || pdpRecordList.PdpRecords[0].NodeAddr != node.WalletAddr {
+		t.Fatalf("unexpected PdpRecords: %v", pdpRecordList.PdpRecords)
+	}
+	nextHeight := pdpRecordList.PdpRecords[0].NextHeight
+	if nextHeight != uint64(chain.Height())+600 {
+		t.Fatalf("NextHeight %d, height %d", nextHeight, chain.Height())
+	}
+
+	chain.AdvanceTime(4 * 3600)
+	if _, err = client.GetFileInfo("SimFile"); err == nil {
+		t.Fatalf("expired file is still stored")
+	}
+	nodeInfo, err = client.GetNodeInfo(node.WalletAddr)
+	if err != nil {
+		t.Fatalf("GetNodeInfo error: %s", err.Error())
+	}
+	if nodeInfo.RestVol != nodeInfo.Volume {
+		t.Fatalf("volume of expired file is not released")
+	}
+}
+
+func TestSimulator_ManualMining(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GenesisTime = testGenesisTime
+	cfg.ManualMining = true
+	chain := NewSimulator(cfg)
+	acc := ont.NewAccount()
+	client := core.InitWithBackend(chain, acc, 0, 20000)
+
+	txHash, err := chain.InvokeNativeContract(0, 20000, core.NewLocalSigner(acc), 0, cfg.ContractAddress,
+		fs.FS_NODE_REGISTER, []interface{}{&fs.FsNodeInfo{
+			Volume:      1024 * 1024,
+			ServiceTime: testGenesisTime + 1000,
+			NodeAddr:    acc.Address,
+		}})
+	if err != nil {
+		t.Fatalf("InvokeNativeContract error: %s", err.Error())
+	}
+	height, err := chain.GetBlockHeightByTxHash(txHash.ToHexString())
+	if err != nil || height != 0 {
+		t.Fatalf("pending tx height %d, err %v", height, err)
+	}
+	if _, err = client.NodeQuery(acc.Address); err == nil {
+		t.Fatalf("pending tx has been executed")
+	}
+
+	chain.Mine()
+	height, err = chain.GetBlockHeightByTxHash(txHash.ToHexString())
+	if err != nil || height != 1 {
+		t.Fatalf("mined tx height %d, err %v", height, err)
+	}
+	if _, err = client.NodeQuery(acc.Address); err != nil {
+		t.Fatalf("NodeQuery error: %s", err.Error())
+	}
+}
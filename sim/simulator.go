@@ -0,0 +1,488 @@
+// Package sim provides an in-memory simulation of the ontfs native contract.
+// A Simulator implements core.ChainBackend, so a Core created with
+// core.InitWithBackend can be exercised without a live Ontology node.
+package sim
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
+	sdkcom "github.com/ontio/ontology-go-sdk/common"
+	ccom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	fs "github.com/ontio/ontology/smartcontract/service/native/ontfs"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	txStateFailed  = byte(0)
+	txStateSuccess = byte(1)
+)
+
+// Config controls the simulated chain.
+type Config struct {
+	GlobalParam     fs.FsGlobalParam
+	ContractAddress ccom.Address
+	// GenesisTime is the timestamp of block 0. Zero means the current time.
+	GenesisTime uint64
+	// BlockInterval is the number of seconds between two simulated blocks.
+	BlockInterval uint64
+	// GasPerInvoke is the gas reported for every invocation, plus GasPerByte
+	// for every byte of its []byte params.
+	GasPerInvoke uint64
+	GasPerByte   uint64
+	// ManualMining keeps submitted transactions pending until Mine or
+	// AdvanceBlocks is called. By default every transaction is mined into
+	// its own block as soon as it is submitted.
+	ManualMining bool
+}
+
+func DefaultGlobalParam() fs.FsGlobalParam {
+	return fs.FsGlobalParam{
+		MinTimeForFileStorage:    60 * 60,
+		ContractInvokeGasFee:     10000000,
+		ChallengeReward:          100000000,
+		FilePerServerPdpTimes:    10,
+		PassportExpire:           9,
+		ChallengeInterval:        60 * 60,
+		NodeMinVolume:            1024 * 1024,
+		NodePerKbPledge:          1024 * 10,
+		FeePerBlockForRead:       10000,
+		FilePerBlockFeeRate:      10,
+		SpacePerBlockFeeRate:     10,
+		GasPerKbForRead:          1000,
+		GasPerKbForSaveWithFile:  1000,
+		GasPerKbForSaveWithSpace: 1000,
+	}
+}
+
+func DefaultConfig() Config {
+	return Config{
+		GlobalParam:     DefaultGlobalParam(),
+		ContractAddress: utils.OntFSContractAddress,
+		BlockInterval:   1,
+		GasPerInvoke:    20000,
+	}
+}
+
+type simTx struct {
+	hash      ccom.Uint256
+	witnesses []ccom.Address
+	gasPrice  uint64
+	method    string
+	params    []interface{}
+	height    uint32
+	event     *sdkcom.SmartContactEvent
+}
+
+// invocation is the contract call referred to by the payload of a
+// transaction built by NewNativeInvokeTransaction.
+type invocation struct {
+	method string
+	params []interface{}
+}
+
+// Simulator is an in-memory ontfs contract. It is safe for concurrent use.
+type Simulator struct {
+	lock        sync.Mutex
+	cfg         Config
+	height      uint32
+	nonce       uint64
+	state       *state
+	txs         map[string]*simTx
+	invocations map[string]*invocation
+	pending     []*simTx
+	blockHashes []ccom.Uint256
+}
+
+func NewSimulator(cfg Config) *Simulator {
+	if cfg.GenesisTime == 0 {
+		cfg.GenesisTime = uint64(time.Now().Unix())
+	}
+	if cfg.BlockInterval == 0 {
+		cfg.BlockInterval = 1
+	}
+	s := &Simulator{
+		cfg:         cfg,
+		state:       newState(),
+		txs:         make(map[string]*simTx),
+		invocations: make(map[string]*invocation),
+	}
+	s.blockHashes = append(s.blockHashes, s.newBlockHash(0))
+	return s
+}
+
+// Height returns the height of the latest simulated block.
+func (s *Simulator) Height() uint32 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.height
+}
+
+// Timestamp returns the timestamp of the latest simulated block.
+func (s *Simulator) Timestamp() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.timestamp()
+}
+
+// Mine produces one block holding all pending transactions.
+func (s *Simulator) Mine() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.mine()
+}
+
+// AdvanceBlocks produces count blocks. Pending transactions go into the first.
+func (s *Simulator) AdvanceBlocks(count uint32) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i := uint32(0); i < count; i++ {
+		s.mine()
+	}
+}
+
+// AdvanceTime produces enough blocks for the chain clock to move by secs.
+func (s *Simulator) AdvanceTime(secs uint64) {
+	blocks := (secs + s.cfg.BlockInterval - 1) / s.cfg.BlockInterval
+	s.AdvanceBlocks(uint32(blocks))
+}
+
+// SetGlobalParam replaces the global parameters returned by the contract.
+func (s *Simulator) SetGlobalParam(param fs.FsGlobalParam) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cfg.GlobalParam = param
+}
+
+func (s *Simulator) InvokeNativeContract(gasPrice, gasLimit uint64, signer core.Signer, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (ccom.Uint256, error) {
+	if signer == nil {
+		return ccom.UINT256_EMPTY, errors.New("InvokeNativeContract signer is nil")
+	}
+	if contractAddress != s.cfg.ContractAddress {
+		return ccom.UINT256_EMPTY, fmt.Errorf("contract %s not found", contractAddress.ToHexString())
+	}
+	if gas := s.gas(params); gasLimit < gas {
+		return ccom.UINT256_EMPTY, fmt.Errorf("gasLimit insufficient, need %d", gas)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nonce++
+	tx := &simTx{
+		hash:      s.newTxHash(signer.Address(), method),
+		witnesses: []ccom.Address{signer.Address()},
+		gasPrice:  gasPrice,
+		method:    method,
+		params:    params,
+	}
+	s.queue(tx)
+	return tx.hash, nil
+}
+
+func (s *Simulator) PreExecInvokeNativeContract(contractAddress ccom.Address, version byte, method string,
+	params []interface{}) (*sdkcom.PreExecResult, error) {
+	if contractAddress != s.cfg.ContractAddress {
+		return nil, fmt.Errorf("contract %s not found", contractAddress.ToHexString())
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ctx := s.newInvokeCtx(nil, s.height)
+	ret, err := s.state.clone().invoke(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	return newPreExecResult(txStateSuccess, s.gas(params), ret, s.notifyEvents(ctx))
+}
+
+// PreExecSignedNativeContract pre-executes an invocation with the witness of
+// signer against the state of the next block. A failed execution is reported
+// through the state of the result, like the failed event of a transaction.
+func (s *Simulator) PreExecSignedNativeContract(gasPrice, gasLimit uint64, signer core.Signer, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (*sdkcom.PreExecResult, error) {
+	if signer == nil {
+		return nil, errors.New("PreExecSignedNativeContract signer is nil")
+	}
+	if contractAddress != s.cfg.ContractAddress {
+		return nil, fmt.Errorf("contract %s not found", contractAddress.ToHexString())
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.preExecSigned([]ccom.Address{signer.Address()}, method, params)
+}
+
+// NewNativeInvokeTransaction builds a transaction of an invocation of the
+// simulated contract. Its payload refers to the invocation, which is kept by
+// the Simulator, so the transaction can only be sent to the same Simulator.
+func (s *Simulator) NewNativeInvokeTransaction(gasPrice, gasLimit uint64, version byte,
+	contractAddress ccom.Address, method string, params []interface{}) (*types.MutableTransaction, error) {
+	if contractAddress != s.cfg.ContractAddress {
+		return nil, fmt.Errorf("contract %s not found", contractAddress.ToHexString())
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nonce++
+	payload := make([]byte, 8)
+	binary.LittleEndian.PutUint64(payload, s.nonce)
+	s.invocations[string(payload)] = &invocation{method: method, params: params}
+	return &types.MutableTransaction{
+		Nonce:    uint32(s.nonce),
+		GasPrice: gasPrice,
+		GasLimit: gasLimit,
+		Payload:  payload,
+	}, nil
+}
+
+// SendTransaction queues a transaction built by NewNativeInvokeTransaction.
+// Every signer of tx is a witness of the invocation, and the payer of tx
+// must be one of them.
+func (s *Simulator) SendTransaction(tx *types.MutableTransaction) (ccom.Uint256, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	inv, witnesses, err := s.verifyTransaction(tx)
+	if err != nil {
+		return ccom.UINT256_EMPTY, err
+	}
+	if gas := s.gas(inv.params); tx.GasLimit < gas {
+		return ccom.UINT256_EMPTY, fmt.Errorf("gasLimit insufficient, need %d", gas)
+	}
+	hash := tx.Hash()
+	if _, ok := s.txs[hash.ToHexString()]; ok {
+		return ccom.UINT256_EMPTY, fmt.Errorf("transaction %s is already sent", hash.ToHexString())
+	}
+	s.queue(&simTx{
+		hash:      hash,
+		witnesses: witnesses,
+		gasPrice:  tx.GasPrice,
+		method:    inv.method,
+		params:    inv.params,
+	})
+	return hash, nil
+}
+
+// PreExecTransaction pre-executes a transaction built by
+// NewNativeInvokeTransaction like PreExecSignedNativeContract does.
+func (s *Simulator) PreExecTransaction(tx *types.MutableTransaction) (*sdkcom.PreExecResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	inv, witnesses, err := s.verifyTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	return s.preExecSigned(witnesses, inv.method, inv.params)
+}
+
+func (s *Simulator) GetBlockHeightByTxHash(txHash string) (uint32, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	tx, ok := s.txs[txHash]
+	if !ok {
+		return 0, fmt.Errorf("unknown transaction %s", txHash)
+	}
+	return tx.height, nil
+}
+
+func (s *Simulator) GetSmartContractEvent(txHash string) (*sdkcom.SmartContactEvent, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	tx, ok := s.txs[txHash]
+	if !ok || tx.height == 0 {
+		return nil, fmt.Errorf("no event for transaction %s", txHash)
+	}
+	return tx.event, nil
+}
+
+func (s *Simulator) GetCurrentBlockHeight() (uint32, error) {
+	return s.Height(), nil
+}
+
+func (s *Simulator) GetBlockHash(height uint32) (ccom.Uint256, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if height > s.height {
+		return ccom.UINT256_EMPTY, fmt.Errorf("block %d not found", height)
+	}
+	return s.blockHashes[height], nil
+}
+
+func (s *Simulator) GetBlockTimestamp(height uint32) (uint32, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if height > s.height {
+		return 0, fmt.Errorf("block %d not found", height)
+	}
+	return uint32(s.cfg.GenesisTime + uint64(height)*s.cfg.BlockInterval), nil
+}
+
+// verifyTransaction checks the signatures of tx and returns its invocation
+// and the addresses of its signers.
+func (s *Simulator) verifyTransaction(tx *types.MutableTransaction) (*invocation, []ccom.Address, error) {
+	inv, ok := s.invocations[string(tx.Payload)]
+	if !ok {
+		return nil, nil, errors.New("transaction payload is not a simulated invocation")
+	}
+	hash := tx.Hash()
+	var witnesses []ccom.Address
+	payerSigned := false
+	for _, sig := range tx.Sigs {
+		if len(sig.PubKeys) != 1 || len(sig.SigData) != 1 {
+			return nil, nil, errors.New("multi-signature transactions are not simulated")
+		}
+		if err := common.Verify(sig.PubKeys[0], hash.ToArray(), sig.SigData[0]); err != nil {
+			return nil, nil, err
+		}
+		witness := types.AddressFromPubKey(sig.PubKeys[0])
+		witnesses = append(witnesses, witness)
+		payerSigned = payerSigned || witness == tx.Payer
+	}
+	if !payerSigned {
+		return nil, nil, fmt.Errorf("transaction is not signed by its payer %s", tx.Payer.ToBase58())
+	}
+	return inv, witnesses, nil
+}
+
+// preExecSigned pre-executes an invocation with witnesses against the state
+// of the next block. A failed invocation returns the error of the contract as
+// its result.
+func (s *Simulator) preExecSigned(witnesses []ccom.Address, method string,
+	params []interface{}) (*sdkcom.PreExecResult, error) {
+	ctx := s.newInvokeCtx(witnesses, s.height+1)
+	ret, err := s.state.clone().invoke(ctx, method, params)
+	if err != nil {
+		return newPreExecResult(txStateFailed, s.gas(params), fs.EncRet(false, []byte(err.Error())), nil)
+	}
+	return newPreExecResult(txStateSuccess, s.gas(params), ret, s.notifyEvents(ctx))
+}
+
+// queue adds tx to the pending transactions and mines it unless mining is
+// manual.
+func (s *Simulator) queue(tx *simTx) {
+	s.txs[tx.hash.ToHexString()] = tx
+	s.pending = append(s.pending, tx)
+	if !s.cfg.ManualMining {
+		s.mine()
+	}
+}
+
+func (s *Simulator) timestamp() uint64 {
+	return s.cfg.GenesisTime + uint64(s.height)*s.cfg.BlockInterval
+}
+
+func (s *Simulator) newInvokeCtx(witnesses []ccom.Address, height uint32) *invokeCtx {
+	return &invokeCtx{
+		witnesses:     witnesses,
+		height:        uint64(height),
+		timestamp:     s.cfg.GenesisTime + uint64(height)*s.cfg.BlockInterval,
+		blockInterval: s.cfg.BlockInterval,
+		global:        s.cfg.GlobalParam,
+	}
+}
+
+func (s *Simulator) mine() {
+	s.height++
+	s.blockHashes = append(s.blockHashes, s.newBlockHash(s.height))
+	s.state.expire(s.timestamp())
+	for _, tx := range s.pending {
+		s.execute(tx)
+		tx.height = s.height
+	}
+	s.pending = nil
+}
+
+// execute runs tx against the state of the current block. A failed
+// transaction leaves the state untouched and is recorded with a failed event.
+func (s *Simulator) execute(tx *simTx) {
+	event := &sdkcom.SmartContactEvent{
+		TxHash:      tx.hash.ToHexString(),
+		State:       txStateSuccess,
+		GasConsumed: s.gas(tx.params) * tx.gasPrice,
+	}
+	ctx := s.newInvokeCtx(tx.witnesses, s.height)
+	working := s.state.clone()
+	if _, err := working.invoke(ctx, tx.method, tx.params); err != nil {
+		event.State = txStateFailed
+	} else {
+		s.state = working
+		event.Notify = s.notifyEvents(ctx)
+	}
+	tx.event = event
+}
+
+// gas is the gas consumed by an invocation with params.
+func (s *Simulator) gas(params []interface{}) uint64 {
+	gas := s.cfg.GasPerInvoke
+	for _, param := range params {
+		if data, ok := param.([]byte); ok {
+			gas += uint64(len(data)) * s.cfg.GasPerByte
+		}
+	}
+	return gas
+}
+
+func (s *Simulator) notifyEvents(ctx *invokeCtx) []*sdkcom.NotifyEventInfo {
+	notify := make([]*sdkcom.NotifyEventInfo, 0, len(ctx.notify))
+	for _, states := range ctx.notify {
+		notify = append(notify, &sdkcom.NotifyEventInfo{
+			ContractAddress: s.cfg.ContractAddress.ToHexString(),
+			States:          states,
+		})
+	}
+	return notify
+}
+
+func (s *Simulator) newTxHash(signer ccom.Address, method string) ccom.Uint256 {
+	var nonce [8]byte
+	binary.LittleEndian.PutUint64(nonce[:], s.nonce)
+	h := sha256.New()
+	h.Write(nonce[:])
+	h.Write(signer[:])
+	h.Write([]byte(method))
+	var hash ccom.Uint256
+	copy(hash[:], h.Sum(nil))
+	return hash
+}
+
+func (s *Simulator) newBlockHash(height uint32) ccom.Uint256 {
+	var data [12]byte
+	binary.LittleEndian.PutUint32(data[:4], height)
+	binary.LittleEndian.PutUint64(data[4:], s.cfg.GenesisTime)
+	return ccom.Uint256(sha256.Sum256(data[:]))
+}
+
+// newPreExecResult builds the sdk result the same way the rpc client does,
+// from the json returned by the node.
+func newPreExecResult(state byte, gas uint64, ret []byte,
+	notify []*sdkcom.NotifyEventInfo) (*sdkcom.PreExecResult, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"State":  state,
+		"Gas":    gas,
+		"Result": hex.EncodeToString(ret),
+		"Notify": notify,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result sdkcom.PreExecResult
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
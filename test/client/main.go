@@ -1,9 +1,10 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"flag"
-	"fmt"
+	"log/slog"
 	"os"
 	"sync"
 	"time"
@@ -16,8 +17,10 @@ import (
 
 const TestFileHash = "FileTest"
 const DefaultPdpInterval = 4 * 60 * 60
+const NodePageSize = 20
 
 var fsClient *core.Core
+var logger core.Logger
 var globalParam *ontfs.FsGlobalParam
 var once sync.Once
 
@@ -65,9 +68,12 @@ func main() {
 	flag.StringVar(&action.newOwner, "newOwner", "", "   changeOwner - newOwner")
 	flag.Parse()
 
+	logger = core.NewSlogLogger(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	core.SetDefaultLogger(logger)
+
 	fsClient = core.Init("./wallet.dat", "pwd", action.rpcAddr, 20000, 20000)
 	if fsClient == nil {
-		fmt.Println("Init error")
+		logger.Error("Init error")
 		return
 	}
 
@@ -106,7 +112,7 @@ func CreateSpace() {
 	timeExpired := uint64(time.Now().Unix()) + 3600*24
 	txHash, err := fsClient.CreateSpace(1024*1024, 3, DefaultPdpInterval, timeExpired)
 	if err != nil {
-		fmt.Println("CreateSpace error: ", err.Error())
+		logger.Error("CreateSpace error", core.ErrField(err))
 		return
 	}
 	fsClient.PollForTxConfirmed(15*time.Second, txHash)
@@ -116,7 +122,7 @@ func CreateSpace() {
 func GetSpaceInfo() {
 	spaceInfo, err := fsClient.GetSpaceInfo()
 	if err != nil {
-		fmt.Println("GetSpaceInfo error: ", err.Error())
+		logger.Error("GetSpaceInfo error", core.ErrField(err))
 		return
 	}
 	common.PrintStruct(*spaceInfo)
@@ -125,22 +131,22 @@ func GetSpaceInfo() {
 func DeleteSpace() {
 	txHash, err := fsClient.DeleteSpace()
 	if err != nil {
-		fmt.Println("DeleteSpace error: ", err.Error())
+		logger.Error("DeleteSpace error", core.ErrField(err))
 		return
 	}
 	fsClient.PollForTxConfirmed(15*time.Second, txHash)
 	spaceInfo, err := fsClient.GetSpaceInfo()
 	if err != nil && spaceInfo == nil {
-		fmt.Println("DeleteSpace success")
+		logger.Info("DeleteSpace success")
 	} else {
-		fmt.Println("DeleteSpace failed")
+		logger.Error("DeleteSpace failed")
 	}
 }
 
 func UpdateSpace() {
 	spaceInfo1, err := fsClient.GetSpaceInfo()
 	if err != nil {
-		fmt.Println("UpdateSpace GetSpaceInfo1 error: ", err.Error())
+		logger.Error("UpdateSpace GetSpaceInfo1 error", core.ErrField(err))
 		return
 	}
 	common.PrintStruct(*spaceInfo1)
@@ -148,22 +154,22 @@ func UpdateSpace() {
 	timeExpired := uint64(time.Now().Unix()) + 3600*24
 	txHash, err := fsClient.UpdateSpace(1024*2048, timeExpired)
 	if err != nil {
-		fmt.Println("UpdateSpace error: ", err.Error())
+		logger.Error("UpdateSpace error", core.ErrField(err))
 		return
 	}
 	fsClient.PollForTxConfirmed(15*time.Second, txHash)
 
 	spaceInfo2, err := fsClient.GetSpaceInfo()
 	if err != nil {
-		fmt.Println("UpdateSpace GetSpaceInfo2 error: ", err.Error())
+		logger.Error("UpdateSpace GetSpaceInfo2 error", core.ErrField(err))
 		return
 	}
 	common.PrintStruct(*spaceInfo2)
 
 	if spaceInfo1.Volume != spaceInfo2.Volume || spaceInfo1.TimeExpired != spaceInfo2.TimeExpired {
-		fmt.Println("UpdateSpace Success")
+		logger.Info("UpdateSpace Success")
 	} else {
-		fmt.Println("UpdateSpace Failed")
+		logger.Error("UpdateSpace Failed")
 	}
 }
 
@@ -171,33 +177,45 @@ func GetGlobalParam() {
 	var err error
 	globalParam, err = fsClient.GetGlobalParam()
 	if err != nil {
-		fmt.Printf("APP GetGlobalParam error: %s\n", err.Error())
+		logger.Error("APP GetGlobalParam error", core.ErrField(err))
 		return
 	}
 	common.PrintStruct(*globalParam)
 }
 
 func GetNodeInfoList() {
-	nodeInfoList, err := fsClient.GetNodeInfoList(9999999)
+	directory, err := fsClient.NodeDirectory(context.Background(), core.NodeFilter{},
+		core.NodeSort{Field: core.NodeSortRestVol, Descending: true})
 	if err != nil {
-		fmt.Printf("APP GetNodeInfoList error: %s\n", err.Error())
+		logger.Error("APP GetNodeInfoList error", core.ErrField(err))
 		return
-	} else {
-		fmt.Printf("NodeInfoListLen: %d\n", len(nodeInfoList.NodesInfo))
-		for _, nodeInfo := range nodeInfoList.NodesInfo {
-			common.PrintStruct(nodeInfo)
+	}
+	count := 0
+	for page := 0; ; page++ {
+		nodes, err := directory.Page(context.Background(), page, NodePageSize)
+		if err != nil {
+			logger.Error("APP GetNodeInfoList error", core.ErrField(err))
+			return
+		}
+		if len(nodes) == 0 {
+			break
+		}
+		for _, node := range nodes {
+			common.PrintStruct(node.FsNodeInfo)
 		}
+		count += len(nodes)
 	}
+	logger.Info("GetNodeInfoList", core.F("count", count))
 }
 
 func GetFileList() {
 	fileHashList, err := fsClient.GetFileList()
 	if err != nil {
-		fmt.Printf("APP GetFileList error: %s\n", err.Error())
+		logger.Error("APP GetFileList error", core.ErrField(err))
 		return
 	} else {
 		for _, fileHash := range fileHashList.FilesH {
-			fmt.Printf("FileHash: %s\n", fmt.Sprintf("%s", fileHash.FHash))
+			logger.Info("file", core.FileHashField(string(fileHash.FHash)))
 		}
 	}
 }
@@ -220,29 +238,29 @@ func StoreFile() {
 
 	_, err, storeErrors := fsClient.StoreFiles(fileStores)
 	if err != nil {
-		fmt.Println("StoreFile error: ", err.Error())
+		logger.Error("StoreFile error", core.ErrField(err))
 		return
 	}
 
-	if len(storeErrors.ObjectErrors) == 0 {
-		fmt.Printf("StoreFile success\n")
+	if storeErrors == nil {
+		logger.Info("StoreFile success")
 		return
 	}
 	for k, v := range storeErrors.ObjectErrors {
-		fmt.Printf("%s | %s\n", k, v)
+		logger.Error("file rejected", core.FileHashField(k), core.F("reason", v))
 	}
 
 	once.Do(func() {
 		if err := connectFs(); err != nil {
-			fmt.Println("connectFs error: ", err.Error())
+			logger.Error("connectFs error", core.ErrField(err))
 			os.Exit(0)
 		}
-		fmt.Println("connection success")
+		logger.Info("connection success")
 	},
 	)
 
 	if err = sendToFs("StoreFile" + "|" + TestFileHash); err != nil {
-		fmt.Println("sendToFs error: ", err.Error())
+		logger.Error("sendToFs error", core.ErrField(err))
 		return
 	}
 	closeConn()
@@ -251,19 +269,19 @@ func StoreFile() {
 func GetFileInfo(fileHash string) {
 	fileInfo, err := fsClient.GetFileInfo(fileHash)
 	if err != nil {
-		fmt.Printf("GetFileInfo fileHash: %s error: %s\n", fileHash, err.Error())
+		logger.Error("GetFileInfo error", core.FileHashField(fileHash), core.ErrField(err))
 		return
 	}
 	common.PrintStruct(*fileInfo)
 
 	filePdpNeedCount := (fileInfo.TimeExpired-fileInfo.TimeStart)/fileInfo.PdpInterval + 1
-	fmt.Printf("TotalPdpNeedCount: %d\n", filePdpNeedCount)
+	logger.Info("TotalPdpNeedCount", core.FileHashField(fileHash), core.F("count", filePdpNeedCount))
 }
 
 func RenewFile(fileHash string) {
 	fileInfo, err := fsClient.GetFileInfo(fileHash)
 	if err != nil {
-		fmt.Printf("RenewFile GetFileInfo fileHash: %s error: %s\n", fileHash, err.Error())
+		logger.Error("RenewFile GetFileInfo error", core.FileHashField(fileHash), core.ErrField(err))
 		return
 	}
 
@@ -275,32 +293,32 @@ func RenewFile(fileHash string) {
 	}
 	_, err, renewErrors := fsClient.RenewFiles(fileRenew)
 	if err != nil {
-		fmt.Println("RenewFile error: ", err.Error())
+		logger.Error("RenewFile error", core.ErrField(err))
 		return
 	}
 
-	if len(renewErrors.ObjectErrors) == 0 {
-		fmt.Printf("RenewFiles success\n")
+	if renewErrors == nil {
+		logger.Info("RenewFiles success")
 		return
 	}
 	for k, v := range renewErrors.ObjectErrors {
-		fmt.Printf("%s | %s\n", k, v)
+		logger.Error("file rejected", core.FileHashField(k), core.F("reason", v))
 	}
 }
 
 func DeleteFile(fileHash string) {
 	_, err, delErrors := fsClient.DeleteFiles([]string{fileHash})
 	if err != nil {
-		fmt.Println("DeleteFile error: ", err.Error())
+		logger.Error("DeleteFile error", core.ErrField(err))
 		return
 	}
 
-	if len(delErrors.ObjectErrors) == 0 {
-		fmt.Printf("DeleteFile success\n")
+	if delErrors == nil {
+		logger.Info("DeleteFile success")
 		return
 	}
 	for k, v := range delErrors.ObjectErrors {
-		fmt.Printf("%s | %s\n", k, v)
+		logger.Error("file rejected", core.FileHashField(k), core.F("reason", v))
 	}
 
 }
@@ -308,7 +326,7 @@ func DeleteFile(fileHash string) {
 func TransferFile(fileHash string, newOwner string) {
 	newOwnerAddr, err := utils.AddressFromBase58(newOwner)
 	if err != nil {
-		fmt.Println("ChangeOwner AddressFromBase58 error: ", err.Error())
+		logger.Error("ChangeOwner AddressFromBase58 error", core.ErrField(err))
 		return
 	}
 
@@ -321,22 +339,22 @@ func TransferFile(fileHash string, newOwner string) {
 
 	_, err, transferErrors := fsClient.TransferFiles(fileTransfer)
 	if err != nil {
-		fmt.Println("ChangeOwner error: ", err.Error())
+		logger.Error("ChangeOwner error", core.ErrField(err))
 		return
 	}
-	if len(transferErrors.ObjectErrors) == 0 {
-		fmt.Printf("TransferFile success\n")
+	if transferErrors == nil {
+		logger.Info("TransferFile success")
 		return
 	}
 	for k, v := range transferErrors.ObjectErrors {
-		fmt.Printf("%s | %s\n", k, v)
+		logger.Error("file rejected", core.FileHashField(k), core.F("reason", v))
 	}
 }
 
 func GetPdpInfoList(fileHash string) {
 	pdpRecordList, err := fsClient.GetFilePdpRecordList(fileHash)
 	if err != nil {
-		fmt.Printf("APP GetFilePdpRecordList error: %s\n", err.Error())
+		logger.Error("APP GetFilePdpRecordList error", core.ErrField(err))
 		return
 	} else {
 		for _, pdpRecord := range pdpRecordList.PdpRecords {
@@ -348,16 +366,16 @@ func GetPdpInfoList(fileHash string) {
 func ReadFile(fileHash string) {
 	fileInfo, err := fsClient.GetFileInfo(fileHash)
 	if err != nil {
-		fmt.Printf("StoreFile GetFileInfo fileHash: %s error: %s\n", fileHash, err.Error())
+		logger.Error("StoreFile GetFileInfo error", core.FileHashField(fileHash), core.ErrField(err))
 		return
 	} else if fileInfo == nil {
-		fmt.Println("StoreFile GetFileInfo failed, fileInfo is nil")
+		logger.Error("StoreFile GetFileInfo failed, fileInfo is nil", core.FileHashField(fileHash))
 		return
 	}
 
 	pdpRecordList, err := fsClient.GetFilePdpRecordList(fileHash)
 	if err != nil {
-		fmt.Printf("APP GetFilePdpRecordList error: %s\n", err.Error())
+		logger.Error("APP GetFilePdpRecordList error", core.ErrField(err))
 		return
 	} else {
 		for _, pdpRecord := range pdpRecordList.PdpRecords {
@@ -374,7 +392,7 @@ func ReadFile(fileHash string) {
 	}
 	readTx, err := fsClient.FileReadPledge(fileHash, readPlans)
 	if err != nil {
-		fmt.Println("FileReadPledge failed error: ", err.Error())
+		logger.Error("FileReadPledge failed error", core.ErrField(err))
 		return
 	}
 	fsClient.PollForTxConfirmed(14*time.Second, readTx)
@@ -382,7 +400,7 @@ func ReadFile(fileHash string) {
 	haveReadBlockNum := uint64(0)
 	readPledge, err := fsClient.GetFileReadPledge(fileHash, fsClient.WalletAddr)
 	if err != nil {
-		fmt.Println("GetFileReadPledge failed error: ", err.Error())
+		logger.Error("GetFileReadPledge failed error", core.ErrField(err))
 		return
 	}
 	for _, readPlan := range readPledge.ReadPlans {
@@ -393,28 +411,28 @@ func ReadFile(fileHash string) {
 
 	once.Do(func() {
 		if err := connectFs(); err != nil {
-			fmt.Println("connectFs error: ", err.Error())
+			logger.Error("connectFs error", core.ErrField(err))
 			os.Exit(0)
 		}
-		fmt.Println("connection success")
+		logger.Info("connection success")
 	},
 	)
 
 	if err = sendToFs("ReadFile" + "|" + fileHash + "|" + fsClient.WalletAddr.ToBase58()); err != nil {
-		fmt.Println("sendToFs error: ", err.Error())
+		logger.Error("sendToFs error", core.ErrField(err))
 		return
 	}
 
 	for i := uint64(0); i < fileInfo.FileBlockCount; i++ {
 		fileReadSlice, err := fsClient.GenFileReadSettleSlice([]byte(fileHash), readPlans[0].NodeAddr, i+haveReadBlockNum, 1)
 		if err != nil {
-			fmt.Printf("GenFileReadSettleSlice error: %s\n", err.Error())
+			logger.Error("GenFileReadSettleSlice error", core.ErrField(err))
 			return
 		}
 		sliceData := common.FileReadSettleSliceSerialize(fileReadSlice)
 		sliceString := hex.EncodeToString(sliceData)
 
-		fmt.Println("sendToFs FileReadSettleSlice")
+		logger.Info("sendToFs FileReadSettleSlice")
 		sendToFs(sliceString)
 	}
 	closeConn()
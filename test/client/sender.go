@@ -2,8 +2,9 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"net"
+
+	"github.com/ontio/ontfs-contract-api/core"
 )
 
 var conn *net.TCPConn
@@ -12,12 +13,12 @@ func connectFs() error {
 	server := "127.0.0.1:1024"
 	tcpAddr, err := net.ResolveTCPAddr("tcp4", server)
 	if err != nil {
-		log.Printf("Fatal error: %s", err.Error())
+		logger.Error("resolve server address error", core.F("addr", server), core.ErrField(err))
 		return err
 	}
 	conn, err = net.DialTCP("tcp", nil, tcpAddr)
 	if err != nil {
-		log.Printf("Fatal error: %s", err.Error())
+		logger.Error("connect server error", core.F("addr", server), core.ErrField(err))
 		return err
 	}
 	return nil
@@ -29,10 +30,10 @@ func sendToFs(words string) error {
 	buffer := make([]byte, 2048)
 	n, err := conn.Read(buffer)
 	if err != nil {
-		log.Println(conn.RemoteAddr().String(), "waiting server back msg error: ", err)
+		logger.Error("waiting server back msg error", core.F("remote", conn.RemoteAddr().String()), core.ErrField(err))
 		return fmt.Errorf("waiting server back msg error: %s", err)
 	}
-	log.Println(conn.RemoteAddr().String(), ": ", string(buffer[:n]))
+	logger.Info("server reply", core.F("remote", conn.RemoteAddr().String()), core.F("msg", string(buffer[:n])))
 	return nil
 }
 
@@ -1,13 +1,13 @@
 package main
 
 import (
-	"log"
 	"net"
 	"strings"
 	"time"
 
 	"encoding/hex"
 	"github.com/ontio/ontfs-contract-api/common"
+	"github.com/ontio/ontfs-contract-api/core"
 	"github.com/ontio/ontology-go-sdk/utils"
 	"github.com/ontio/ontology/smartcontract/service/native/ontfs"
 )
@@ -15,12 +15,12 @@ import (
 func FsServer() {
 	netListen, err := net.Listen("tcp", "localhost:1024")
 	if err != nil {
-		log.Println("net Listen error: ", err.Error())
+		logger.Error("net Listen error", core.ErrField(err))
 		return
 	}
 
 	defer netListen.Close()
-	log.Println("Waiting for clients ...")
+	logger.Info("waiting for clients", core.F("addr", netListen.Addr().String()))
 
 	for {
 		conn, err := netListen.Accept()
@@ -28,7 +28,7 @@ func FsServer() {
 			continue
 		}
 
-		log.Println(conn.RemoteAddr().String(), "tcp connect success")
+		logger.Info("tcp connect success", core.F("remote", conn.RemoteAddr().String()))
 		handleConnection(conn)
 	}
 }
@@ -38,11 +38,11 @@ func handleConnection(conn net.Conn) {
 
 	n, err := conn.Read(buffer)
 	if err != nil {
-		log.Println(conn.RemoteAddr().String(), "Connection error: ", err)
+		logger.Error("connection error", core.F("remote", conn.RemoteAddr().String()), core.ErrField(err))
 		return
 	}
 	msg := string(buffer[:n])
-	log.Println(conn.RemoteAddr().String(), "Receive data:\n", msg)
+	logger.Info("receive data", core.F("remote", conn.RemoteAddr().String()), core.F("msg", msg))
 	conn.Write([]byte("Message Received"))
 
 	parts := strings.Split(msg, "|")
@@ -54,43 +54,43 @@ func handleConnection(conn net.Conn) {
 }
 
 func PDP(fileHash string) {
-	log.Printf("PDP init, FileHash: [%s]", fileHash)
+	logger.Info("PDP init", core.FileHashField(fileHash))
 
 	fileInfo, err := fsCore.GetFileInfo(fileHash)
 	if err != nil {
-		log.Printf("GetFileInfo error: %s", err.Error())
+		logger.Error("GetFileInfo error", core.FileHashField(fileHash), core.ErrField(err))
 		return
 	}
 	filePdpNeedCount := (fileInfo.TimeExpired-fileInfo.TimeStart)/fileInfo.PdpInterval + 1
-	log.Printf("TotalPdpNeedCount: %d", filePdpNeedCount)
+	logger.Info("TotalPdpNeedCount", core.FileHashField(fileHash), core.F("count", filePdpNeedCount))
 	common.PrintStruct(*fileInfo)
 
 	fileHashStr := string(fileInfo.FileHash)
 
-	log.Printf("FileProve first time")
+	logger.Info("FileProve first time", core.FileHashField(fileHash))
 	_, err = fsCore.FileProve(fileHashStr, []byte("test"), 8)
 	if err != nil {
-		log.Printf("First FileProve error: %s", err.Error())
+		logger.Error("first FileProve error", core.FileHashField(fileHash), core.ErrField(err))
 	}
 
 	for {
 		time.Sleep(time.Duration(fileInfo.PdpInterval * uint64(time.Second)))
 		fileInfo1, err := fsCore.GetFileInfo(fileHash)
 		if err != nil || fileInfo == nil {
-			log.Printf("File is not exist. Return")
+			logger.Info("file does not exist", core.FileHashField(fileHash))
 			return
 		}
 		filePdpNeedCount := (fileInfo1.TimeExpired-fileInfo1.TimeStart)/fileInfo.PdpInterval + 1
-		log.Printf("TotalPdpNeedCount: %d", filePdpNeedCount)
+		logger.Info("TotalPdpNeedCount", core.FileHashField(fileHash), core.F("count", filePdpNeedCount))
 
-		log.Printf("FileProve begin")
+		logger.Info("FileProve begin", core.FileHashField(fileHash))
 		pdpInfoList, err := fsCore.GetFilePdpRecordList(fileHashStr)
 		if err != nil {
-			log.Printf("GetFilePdpRecordList error: %s", err.Error())
+			logger.Error("GetFilePdpRecordList error", core.FileHashField(fileHash), core.ErrField(err))
 			break
 		}
 		if len(pdpInfoList.PdpRecords) == 0 {
-			log.Println("GetFilePdpRecordList error: PdpRecords Length is 0")
+			logger.Error("GetFilePdpRecordList error: no pdp record", core.FileHashField(fileHash))
 			break
 		}
 
@@ -99,9 +99,9 @@ func PDP(fileHash string) {
 				common.PrintStruct(pdpInfo)
 				_, err = fsCore.FileProve(fileHashStr, []byte(fileHash), pdpInfo.NextHeight)
 				if err != nil {
-					log.Printf("FileProve error: %s", err.Error())
+					logger.Error("FileProve error", core.FileHashField(fileHash), core.ErrField(err))
 				}
-				log.Printf("FileProve end")
+				logger.Info("FileProve end", core.FileHashField(fileHash), core.NodeAddrField(pdpInfo.NodeAddr))
 			}
 		}
 	}
@@ -112,13 +112,13 @@ func FileRead(conn net.Conn, fileHash string, downloader string) {
 	var fileReadSettleSlice *ontfs.FileReadSettleSlice
 	downloaderAddr, err := utils.AddressFromBase58(downloader)
 	if err != nil {
-		log.Printf("FileRead AddressFromBase58 error: %s", err.Error())
+		logger.Error("FileRead AddressFromBase58 error", core.F("downloader", downloader), core.ErrField(err))
 		return
 	}
 
 	readPledge, err := fsCore.GetFileReadPledge(fileHash, downloaderAddr)
 	if err != nil {
-		log.Printf("FileRead GetFileReadPledge error: %s", err.Error())
+		logger.Error("FileRead GetFileReadPledge error", core.FileHashField(fileHash), core.ErrField(err))
 		return
 	}
 	common.PrintStruct(*readPledge)
@@ -127,48 +127,48 @@ func FileRead(conn net.Conn, fileHash string, downloader string) {
 		if readPledge.ReadPlans[0].NodeAddr.ToBase58() == fsCore.WalletAddr.ToBase58() {
 			for i := uint64(0); i < readPlan.MaxReadBlockNum; i++ {
 				if i+readPlan.HaveReadBlockNum >= readPlan.MaxReadBlockNum {
-					log.Println("FileReadPledge is not valid")
+					logger.Error("FileReadPledge is not valid", core.FileHashField(fileHash))
 					return
 				}
 
 				n, err := conn.Read(buffer)
 				if err != nil {
-					log.Println(conn.RemoteAddr().String(), "Connection error: ", err.Error())
+					logger.Error("connection error", core.F("remote", conn.RemoteAddr().String()), core.ErrField(err))
 					return
 				}
-				log.Println("Received FileReadSettleSlice")
+				logger.Info("received FileReadSettleSlice", core.FileHashField(fileHash))
 				msg := string(buffer[:n])
 				sliceData, err := hex.DecodeString(msg)
 				if err != nil {
-					log.Println("DecodeString error: ", err)
+					logger.Error("DecodeString error", core.ErrField(err))
 					return
 				}
 
 				fileReadSettleSlice, err = common.FileReadSettleSliceDeserialize(sliceData)
 				if err != nil {
-					log.Println("FileReadSettleSliceDeserialize error: ", err.Error())
+					logger.Error("FileReadSettleSliceDeserialize error", core.ErrField(err))
 					return
 				}
 				ret, err := fsCore.VerifyFileReadSettleSlice(fileReadSettleSlice)
 				if err != nil {
-					log.Println("VerifyFileReadSettleSlice error: ", err.Error())
+					logger.Error("VerifyFileReadSettleSlice error", core.FileHashField(fileHash), core.ErrField(err))
 					return
 				}
 				if !ret {
-					log.Println("VerifyFileReadSettleSlice failed")
+					logger.Error("VerifyFileReadSettleSlice failed", core.FileHashField(fileHash))
 					return
 				}
 				conn.Write([]byte("FileReadSettleSlice Received"))
-				log.Println("Send FileReadSettleSlice ACK")
+				logger.Info("send FileReadSettleSlice ACK", core.FileHashField(fileHash))
 			}
-			log.Println("FileReadProfitSettle...")
+			logger.Info("FileReadProfitSettle", core.FileHashField(fileHash), core.NodeAddrField(readPlan.NodeAddr))
 			settleTx, err := fsCore.FileReadProfitSettle(fileReadSettleSlice)
 			if err != nil {
-				log.Println("FileReadProfitSettle failed")
+				logger.Error("FileReadProfitSettle failed", core.FileHashField(fileHash), core.ErrField(err))
 				return
 			}
 			fsCore.PollForTxConfirmed(14*time.Second, settleTx)
-			log.Println("FileReadProfitSettle over")
+			logger.Info("FileReadProfitSettle over", core.FileHashField(fileHash), core.TxHashField(settleTx))
 		}
 	}
 }
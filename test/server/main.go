@@ -2,17 +2,19 @@ package main
 
 import (
 	"flag"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/ontio/ontfs-contract-api/common"
 	"github.com/ontio/ontfs-contract-api/core"
-	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/smartcontract/service/native/ontfs"
 )
 
 const TestFileHash = "FileTest"
 
 var fsCore *core.Core
+var logger core.Logger
 var globalParam *ontfs.FsGlobalParam
 
 var action = struct {
@@ -37,9 +39,12 @@ func main() {
 	flag.StringVar(&action.fileHash, "fileHash", TestFileHash, "fileHash")
 	flag.Parse()
 
+	logger = core.NewSlogLogger(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	core.SetDefaultLogger(logger)
+
 	fsCore = core.Init("./wallet.dat", "pwd", "http://localhost:33894", 0, 20000)
 	if fsCore == nil {
-		log.Error("fsNode Init error")
+		logger.Error("fsNode Init error")
 		return
 	}
 
@@ -66,7 +71,7 @@ func GetGlobalParam() {
 	var err error
 	globalParam, err = fsCore.GetGlobalParam()
 	if err != nil {
-		log.Error("GetGlobalParam error")
+		logger.Error("GetGlobalParam error", core.ErrField(err))
 		return
 	}
 	common.PrintStruct(*globalParam)
@@ -76,7 +81,7 @@ func RegisterNode() {
 	serviceDueTime := time.Now().Unix() + 100000
 	_, err := fsCore.NodeRegister(1024*1024*1024, uint64(serviceDueTime), 4*60*60, "tcp://10.0.1.66:3389")
 	if err != nil {
-		log.Errorf("NodeRegister error: %s", err.Error())
+		logger.Error("NodeRegister error", core.ErrField(err))
 		return
 	}
 }
@@ -84,7 +89,7 @@ func RegisterNode() {
 func QueryNode() {
 	nodeInfo, err := fsCore.NodeQuery(fsCore.WalletAddr)
 	if err != nil {
-		log.Errorf("NodeQuery error: %s", err.Error())
+		logger.Error("NodeQuery error", core.NodeAddrField(fsCore.WalletAddr), core.ErrField(err))
 		return
 	} else {
 		common.PrintStruct(*nodeInfo)
@@ -95,7 +100,7 @@ func UpdateNode() {
 	serviceDueTime := time.Now().Unix() + 100000
 	_, err := fsCore.NodeUpdate(1024*1024*1024, uint64(serviceDueTime), 4*60*60, "tcp://10.0.1.66:1004")
 	if err != nil {
-		log.Errorf("NodeUpdate error: %s", err.Error())
+		logger.Error("NodeUpdate error", core.ErrField(err))
 		return
 	}
 }
@@ -103,7 +108,7 @@ func UpdateNode() {
 func CancelNode() {
 	_, err := fsCore.NodeCancel()
 	if err != nil {
-		log.Errorf("NodeCancel error: %s", err.Error())
+		logger.Error("NodeCancel error", core.ErrField(err))
 		return
 	}
 }
@@ -111,7 +116,7 @@ func CancelNode() {
 func WithDrawProfit() {
 	_, err := fsCore.NodeWithDrawProfit()
 	if err != nil {
-		log.Errorf("NodeWithDrawProfit error: %s", err.Error())
+		logger.Error("NodeWithDrawProfit error", core.ErrField(err))
 		return
 	}
 }
@@ -119,7 +124,7 @@ func WithDrawProfit() {
 func GetFileInfo(fileHash string) {
 	fileInfo, err := fsCore.GetFileInfo(fileHash)
 	if err != nil {
-		log.Errorf("GetFileInfo fileHash: %s error: %s", fileHash, err.Error())
+		logger.Error("GetFileInfo error", core.FileHashField(fileHash), core.ErrField(err))
 		return
 	}
 	common.PrintStruct(*fileInfo)
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ontio/ontfs-contract-api/core"
+	"github.com/ontio/ontfs-contract-api/signer"
+	ont "github.com/ontio/ontology-go-sdk"
+	"github.com/ontio/ontology/common/log"
+)
+
+var action = struct {
+	walletPath string
+	password   string
+	socketPath string
+}{}
+
+func main() {
+	flag.StringVar(&action.walletPath, "wallet", "./wallet.dat", "wallet path")
+	flag.StringVar(&action.password, "password", "pwd", "wallet password")
+	flag.StringVar(&action.socketPath, "socket", "./signer.sock", "unix socket path")
+	flag.Parse()
+
+	wallet, err := ont.NewOntologySdk().OpenWallet(action.walletPath)
+	if err != nil {
+		log.Errorf("OpenWallet error: %s", err.Error())
+		return
+	}
+	acc, err := wallet.GetDefaultAccount([]byte(action.password))
+	if err != nil {
+		log.Errorf("GetDefaultAccount error: %s", err.Error())
+		return
+	}
+
+	server := signer.NewServer(core.NewLocalSigner(acc))
+	if err = server.Listen(action.socketPath); err != nil {
+		log.Errorf("Listen error: %s", err.Error())
+		return
+	}
+	defer os.Remove(action.socketPath)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		server.Close()
+	}()
+
+	log.Infof("signing for %s on %s", acc.Address.ToBase58(), action.socketPath)
+	if err = server.Serve(); err != nil {
+		log.Errorf("Serve error: %s", err.Error())
+	}
+}